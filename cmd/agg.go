@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"iter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// aggCmd represents the agg command
+var aggCmd = &cobra.Command{
+	Use:   "agg",
+	Short: "count, sum, average and find the least or greatest values of items",
+	Long: `Aggregate the items of a scan or query on the client, optionally grouped by
+the values of fields, and print a record per group with the group by fields
+and the aggregates, named count, sum_<field>, avg_<field>, min_<field> and
+max_<field>. Only the aggregates of each group are kept in memory, not the
+items. Without aggregate flags the items are counted.
+
+Items are read like scan and query read them, e.g. --filter keeps the items
+matching the filters before they are aggregated.
+
+Sum and avg ignore values which aren't numbers, avg is null without any. Min
+and max compare numbers and strings, e.g. dates, like --sort-by.
+
+  ddb agg scan orders --group-by status --count --sum total
+  ddb agg scan orders --filter "total>10" --sum total
+  ddb agg query orders a --avg total --min order --max order
+  ddb agg scan orders --group-by customer --count --sort-by count:desc`,
+}
+
+var aggScanCmd = &cobra.Command{
+	Use:   "scan <table>",
+	Short: "aggregate the items of a scan",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := scanItems(cmd, args)
+		if err != nil {
+			return err
+		}
+		return runAgg(cmd, items)
+	},
+}
+
+var aggQueryCmd = &cobra.Command{
+	Use:   "query <table[:index]> <partition> [sort]",
+	Short: "aggregate the items of a query",
+	Args:  cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := queryItems(cmd, args)
+		if err != nil {
+			return err
+		}
+		return runAgg(cmd, items)
+	},
+}
+
+func runAgg(cmd *cobra.Command, items iter.Seq2[internal.Item, error]) error {
+	flags := cmd.Flags()
+	groupBy, _ := flags.GetStringSlice("group-by")
+	var aggregations []internal.Aggregation
+	if count, _ := flags.GetBool("count"); count {
+		aggregations = append(aggregations, internal.Aggregation{Function: "count"})
+	}
+	for _, function := range internal.AggregateFunctions[1:] {
+		fields, _ := flags.GetStringSlice(function)
+		for _, field := range fields {
+			aggregations = append(aggregations, internal.Aggregation{Function: function, Field: field})
+		}
+	}
+	if len(aggregations) == 0 {
+		aggregations = append(aggregations, internal.Aggregation{Function: "count"})
+	}
+	aggregator, err := internal.NewAggregator(groupBy, aggregations)
+	if err != nil {
+		return err
+	}
+
+	for item, err := range internal.UnmarshalItems(items) {
+		if err != nil {
+			return err
+		}
+		if err := aggregator.Add(item); err != nil {
+			return err
+		}
+	}
+	results := aggregator.Results()
+	return printRecords(func(yield func(map[string]any, error) bool) {
+		for _, result := range results {
+			if !yield(result, nil) {
+				return
+			}
+		}
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(aggCmd)
+	aggCmd.AddCommand(aggScanCmd, aggQueryCmd)
+
+	aggCmd.PersistentFlags().StringSlice("group-by", []string{}, "fields whose values group the items, e.g. status or address.city")
+	aggCmd.PersistentFlags().Bool("count", false, "count the items")
+	aggCmd.PersistentFlags().StringSlice("sum", []string{}, "fields to sum")
+	aggCmd.PersistentFlags().StringSlice("avg", []string{}, "fields to average")
+	aggCmd.PersistentFlags().StringSlice("min", []string{}, "fields to find the least value of")
+	aggCmd.PersistentFlags().StringSlice("max", []string{}, "fields to find the greatest value of")
+}
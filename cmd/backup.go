@@ -0,0 +1,191 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "create, list, describe and delete on-demand backups",
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create <table>",
+	Short: "back up a table",
+	Long: `Create an on-demand backup of a table, named after the table and the current
+time unless --name is given, and print its details once it is available.
+
+  ddb backup create orders
+  ddb backup create orders --name orders-before-migration`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackupCreate,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list [table]",
+	Short: "list the backups of a table or of all tables",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackupList,
+}
+
+var backupDescribeCmd = &cobra.Command{
+	Use:   "describe <backup-arn>",
+	Short: "print the description of a backup",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackupDescribe,
+}
+
+var backupDeleteCmd = &cobra.Command{
+	Use:   "delete <backup-arn>",
+	Short: "delete a backup",
+	Long:  `Delete a backup, after confirmation unless --yes is given.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackupDelete,
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup-arn|table> <new-table>",
+	Short: "restore a backup or a point in time to a new table",
+	Long: `Restore a backup to a new table, or with --to-time restore a table with point
+in time recovery to a new table as it was at that time. The time is RFC3339 or
+latest for the latest restorable time, see the restorable period with
+ddb table describe. Stream, ttl and point in time recovery settings aren't
+restored.
+
+  ddb restore arn:aws:dynamodb:...:table/orders/backup/0169... orders-restored
+  ddb restore orders orders-restored --to-time 2025-06-01T12:00:00Z
+  ddb restore orders orders-restored --to-time latest`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRestore,
+}
+
+func runBackupCreate(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	backupName, _ := cmd.Flags().GetString("name")
+	if backupName == "" {
+		backupName = fmt.Sprintf("%s-%s", tableName, time.Now().UTC().Format("20060102-150405"))
+	}
+	logger.Debug(fmt.Sprintf("creating backup %s of %s", backupName, tableName))
+	details, err := internal.CreateBackup(cmd.Context(), client, tableName, backupName)
+	if err != nil {
+		return err
+	}
+	if wait, _ := cmd.Flags().GetBool("wait"); !wait {
+		return printDescription(details)
+	}
+	description, err := internal.WaitForBackup(cmd.Context(), client, *details.BackupArn)
+	if err != nil {
+		return err
+	}
+	return printDescription(description.BackupDetails)
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	tableName := ""
+	if len(args) == 1 {
+		tableName = resolveTableName(args[0])
+	}
+	typeArg, _ := cmd.Flags().GetString("type")
+	backupType := types.BackupTypeFilter(strings.ReplaceAll(strings.ToUpper(typeArg), "-", "_"))
+	if !slices.Contains(backupType.Values(), backupType) {
+		return fmt.Errorf("invalid --type %q, expected user, system, aws-backup or all", typeArg)
+	}
+	return printDescriptions(internal.ListBackups(cmd.Context(), client, tableName, backupType))
+}
+
+func runBackupDescribe(cmd *cobra.Command, args []string) error {
+	description, err := internal.DescribeBackup(cmd.Context(), client, args[0])
+	if err != nil {
+		return err
+	}
+	return printDescription(description)
+}
+
+func runBackupDelete(cmd *cobra.Command, args []string) error {
+	description, err := internal.DescribeBackup(cmd.Context(), client, args[0])
+	if err != nil {
+		return err
+	}
+	tableName := aws.ToString(description.SourceTableDetails.TableName)
+	backupName := aws.ToString(description.BackupDetails.BackupName)
+	preview := fmt.Sprintf("delete backup %s of table %s", backupName, tableName)
+	if err := guardTableWrite(DestructiveAccess, preview, tableName); err != nil {
+		return err
+	}
+	if err := internal.DeleteBackup(cmd.Context(), client, args[0]); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("backup %s is deleted", backupName))
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	newTableName := resolveTableName(args[1])
+	toTime, _ := cmd.Flags().GetString("to-time")
+	if err := guardTableWrite(WriteAccess, "restore table "+newTableName, newTableName); err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("to-time") {
+		restoreTime, err := parseRestoreTime(toTime)
+		if err != nil {
+			return err
+		}
+		sourceTableName := resolveTableName(args[0])
+		logger.Debug(fmt.Sprintf("restoring %s at %s to %s", sourceTableName, toTime, newTableName))
+		if err := internal.RestoreTableToPointInTime(cmd.Context(), client, sourceTableName, newTableName, restoreTime); err != nil {
+			return err
+		}
+	} else {
+		logger.Debug(fmt.Sprintf("restoring %s to %s", args[0], newTableName))
+		if err := internal.RestoreTableFromBackup(cmd.Context(), client, args[0], newTableName); err != nil {
+			return err
+		}
+	}
+	if wait, _ := cmd.Flags().GetBool("wait"); !wait {
+		logger.Info(fmt.Sprintf("restoring table %s", newTableName))
+		return nil
+	}
+	if _, err := internal.WaitForActive(cmd.Context(), client, newTableName); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("table %s is restored", newTableName))
+	return nil
+}
+
+// parseRestoreTime parses an RFC3339 time, latest is the zero time
+func parseRestoreTime(arg string) (time.Time, error) {
+	if strings.EqualFold(arg, "latest") {
+		return time.Time{}, nil
+	}
+	restoreTime, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --to-time %q, expected RFC3339 or latest [%w]", arg, err)
+	}
+	return restoreTime, nil
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	backupCmd.AddCommand(backupCreateCmd, backupListCmd, backupDescribeCmd, backupDeleteCmd)
+
+	backupCreateCmd.Flags().String("name", "", "name of the backup (default <table>-<time>)")
+	backupCreateCmd.Flags().Bool("wait", true, "wait until the backup is available")
+	backupListCmd.Flags().String("type", "user", "backups to list, user, system, aws-backup or all")
+	restoreCmd.Flags().String("to-time", "", "restore the table as it was at this time, RFC3339 or latest")
+	restoreCmd.Flags().Bool("wait", true, "wait until the restored table is active")
+}
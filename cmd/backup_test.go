@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func countItems(t *testing.T, fake *internal.FakeDynamodb, tableName string) int {
+	t.Helper()
+	output, err := fake.Scan(context.TODO(), &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(output.Items)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	fake := newOrdersFake(t)
+	output, err := runCommand(t, fake, "backup", "create", "orders", "--name", "before", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details struct{ BackupArn, BackupName, BackupStatus string }
+	if err := json.Unmarshal([]byte(output), &details); err != nil || details.BackupName != "before" || details.BackupStatus != "AVAILABLE" {
+		t.Fatalf("got %s, %v want an available backup", output, err)
+	}
+	output, err = runCommand(t, fake, "backup", "list", "orders", "--pretty=false", "--color=false")
+	if err != nil || strings.Count(output, details.BackupArn) != 1 {
+		t.Errorf("got %q, %v want the backup", output, err)
+	}
+	if output, err = runCommand(t, fake, "backup", "describe", details.BackupArn, "--pretty=false", "--color=false"); err != nil || !strings.Contains(output, `"ItemCount":3`) {
+		t.Errorf("got %q, %v want the description with 3 items", output, err)
+	}
+	output, err = runCommand(t, fake, "backup", "list", "--jq", ".BackupName", "--pretty=false", "--color=false")
+	if err != nil || strings.TrimSpace(output) != `"before"` {
+		t.Errorf("got %q, %v want the backup name from --jq", output, err)
+	}
+	output, err = runCommand(t, fake, "backup", "describe", details.BackupArn, "--template", "{{.SourceTableDetails.ItemCount}}")
+	if err != nil || strings.TrimSpace(output) != "3" {
+		t.Errorf("got %q, %v want the item count from --template", output, err)
+	}
+
+	key, err := internal.MarshalItem(map[string]any{"customer": "a", "order": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.DeleteItem(context.TODO(), fake, "orders", key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "restore", details.BackupArn, "orders-restored"); err != nil {
+		t.Fatal(err)
+	}
+	if got := countItems(t, fake, "orders-restored"); got != 3 {
+		t.Errorf("got %d restored items, want 3", got)
+	}
+	if _, err := runCommand(t, fake, "restore", details.BackupArn, "orders-restored"); err == nil {
+		t.Errorf("got no error restoring to an existing table")
+	}
+
+	if _, err := runCommand(t, fake, "backup", "delete", details.BackupArn); err == nil {
+		t.Errorf("got no error deleting without confirmation")
+	}
+	if _, err := runCommand(t, fake, "backup", "delete", details.BackupArn, "--yes"); err == nil {
+		t.Errorf("got no error deleting without a terminal or --force")
+	}
+	if _, err := runCommand(t, fake, "backup", "delete", details.BackupArn, "--yes", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := runCommand(t, fake, "backup", "list"); err != nil || output != "" {
+		t.Errorf("got %q, %v want no backups", output, err)
+	}
+}
+
+func TestPointInTimeRestore(t *testing.T) {
+	fake := newOrdersFake(t)
+	output, err := runCommand(t, fake, "table", "describe", "orders", "--pretty=false", "--color=false")
+	if err != nil || !strings.Contains(output, `"PointInTimeRecoveryStatus":"DISABLED"`) {
+		t.Errorf("got %q, %v want point in time recovery disabled", output, err)
+	}
+	if _, err := runCommand(t, fake, "restore", "orders", "orders-restored", "--to-time", "latest"); err == nil {
+		t.Errorf("got no error without point in time recovery")
+	}
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--point-in-time-recovery"); err != nil {
+		t.Fatal(err)
+	}
+	output, err = runCommand(t, fake, "table", "describe", "orders", "--pretty=false", "--color=false")
+	if err != nil || !strings.Contains(output, `"PointInTimeRecoveryStatus":"ENABLED"`) {
+		t.Errorf("got %q, %v want point in time recovery enabled", output, err)
+	}
+	if _, err := runCommand(t, fake, "restore", "orders", "orders-restored", "--to-time", "2001-01-01T00:00:00Z"); err == nil {
+		t.Errorf("got no error restoring before the restorable period")
+	}
+	if _, err := runCommand(t, fake, "restore", "orders", "orders-restored", "--to-time", "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if got := countItems(t, fake, "orders-restored"); got != 3 {
+		t.Errorf("got %d restored items, want 3", got)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// codegenCmd represents the codegen command
+var codegenCmd = &cobra.Command{
+	Use:   "codegen",
+	Short: "generate code for the items of a table",
+}
+
+var codegenGoCmd = &cobra.Command{
+	Use:   "go <table>",
+	Short: "generate go structs for the items of a table",
+	Long: `Generate go structs with dynamodbav tags for the items of a table, from its
+keys and the attributes of sampled items like ddb schema infer. Items are
+grouped into one struct per entity by the prefix of the sort key, attributes
+missing from some items are pointers or omitted when empty, and maps become
+nested structs.
+
+Every struct has a key type, a method returning the key of an item, and a
+function building a key which adds the entity prefix to the sort key.
+
+  ddb codegen go orders > model/orders.go
+  ddb codegen go app --all --package store`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCodegenGo,
+}
+
+func runCodegenGo(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	flags := cmd.Flags()
+	limit, _ := flags.GetInt("limit")
+	all, _ := flags.GetBool("all")
+	if limit < 1 && !all {
+		return fmt.Errorf("--limit must be positive, use --all to scan every item")
+	}
+	separator, _ := flags.GetString("separator")
+	packageName, _ := flags.GetString("package")
+	if packageName == "" {
+		packageName = packageNameOf(tableName)
+	}
+
+	keys, err := internal.GetTableKeys(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	schema, err := inferSchema(cmd, tableName, "", limit, all, separator)
+	if err != nil {
+		return err
+	}
+	source, err := internal.GenerateGo(schema, internal.GoOptions{
+		Package:   packageName,
+		TableName: tableName,
+		Keys:      keys,
+		Command:   "ddb codegen go " + args[0],
+	})
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(source)
+	return err
+}
+
+// packageNameOf turns a table name into a package name, e.g. app-orders into apporders
+func packageNameOf(tableName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, tableName)
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "model" + name
+	}
+	return name
+}
+
+func init() {
+	rootCmd.AddCommand(codegenCmd)
+	codegenCmd.AddCommand(codegenGoCmd)
+
+	codegenGoCmd.Flags().String("package", "", "package of the generated code (default the table name)")
+	codegenGoCmd.Flags().Int("limit", 1000, "number of items to sample")
+	codegenGoCmd.Flags().Bool("all", false, "scan every item instead of a sample")
+	codegenGoCmd.Flags().String("group-by", "", "attribute whose prefix names the entity of an item, empty for a single struct (default the sort key, or the partition key)")
+	codegenGoCmd.Flags().String("separator", "#", "separator ending the entity prefix of the group by attribute")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodegenGo(t *testing.T) {
+	fake := newOrdersFake(t)
+	output, err := runCommand(t, fake, "codegen", "go", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package orders\n",
+		"type Orders struct {",
+		"Customer string `dynamodbav:\"customer\"`",
+		"Order    int64  `dynamodbav:\"order\"`",
+		"func NewOrdersKey(customer string, order int64) OrdersKey {",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("got\n%s\nwant it to contain %q", output, want)
+		}
+	}
+	if packageName := packageNameOf("2025-app.orders"); packageName != "model2025apporders" {
+		t.Errorf("got package %s", packageName)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// settings a named environment can hold
+var envKeys = []string{"profile", "region", "endpoint-url", "role-arn", "table-prefix", "table-suffix", "aliases", "read-only", "read-only-tables",
+	"confirm-threshold", "retry-mode", "max-attempts", "max-backoff", "rate"}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage named environments",
+	Long: `Manage the named environments in the config file.
+
+An environment groups connection settings under envs.<name>, e.g.
+
+  envs:
+    dev:
+      endpoint-url: http://localhost:8000
+    prod:
+      profile: prod
+      region: eu-west-1
+
+and is selected with --env or $DDB_ENV. Names of environments, like all keys
+of the config file, are case insensitive and read in lower case, so config set
+only accepts lower case names.
+
+Table names given to commands are expanded with table-prefix and table-suffix,
+unless they already have them or are one of the aliases, e.g.
+
+  aliases:
+    orders: acme-prod-eu-west-1-orders-v3
+  table-prefix: acme-prod-eu-west-1-
+
+Writes are refused when read-only is true or the table matches one of the
+read-only-tables patterns, and need confirmation when they affect more items,
+or run more sql write statements, than confirm-threshold (default 1).`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list environments",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show [env]",
+	Short: "show the settings of an environment",
+	Long:  `Show the settings of an environment, by default the selected one.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigShow,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <env> <key> <value>",
+	Short: "change a setting of an environment",
+	Long: fmt.Sprintf(`Change a setting of an environment in the config file, creating the
+environment and the config file if needed. Valid keys are: %s
+
+read-only-tables takes comma separated patterns and aliases comma separated
+name=table pairs, both replace the previous value, e.g.
+
+  ddb config set prod read-only-tables '*-prod-*,audit'
+  ddb config set prod aliases orders=acme-prod-orders-v3,users=acme-prod-users`, strings.Join(envKeys, ", ")),
+	Args: cobra.ExactArgs(3),
+	RunE: runConfigSet,
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	envs := viper.GetStringMap("envs")
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	active := viper.GetString("env")
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+	}
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	env := viper.GetString("env")
+	if len(args) == 1 {
+		env = args[0]
+	}
+	if env == "" {
+		return fmt.Errorf("no environment selected, pass one or use --env")
+	}
+	if !viper.IsSet("envs." + env) {
+		return fmt.Errorf("environment %s is not defined in the config file", env)
+	}
+	settingsJson, err := json.Marshal(viper.GetStringMap("envs." + env))
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings as json: %w", err)
+	}
+	internal.PrintJson(settingsJson, viper.GetBool("pretty"), viper.GetBool("color"))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	env, key, value := args[0], args[1], args[2]
+	if env != strings.ToLower(env) {
+		return fmt.Errorf("invalid environment %s, names are case insensitive and must be lower case", env)
+	}
+	if !slices.Contains(envKeys, key) {
+		return fmt.Errorf("unknown setting %s, valid keys are: %s", key, strings.Join(envKeys, ", "))
+	}
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return fmt.Errorf("failed to parse config file [%w]", err)
+	}
+	node, err := configValue(key, value)
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(&document, []string{"envs", env, key}, node); err != nil {
+		return err
+	}
+	contents, err = encodeConfig(&document, configIndent(contents))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	logger.Debug(fmt.Sprintf("set envs.%s.%s in %s", env, key, path))
+	return nil
+}
+
+// resolveTableName expands an alias or adds the configured table prefix and suffix
+func resolveTableName(name string) string {
+	resolved := name
+	if alias := viper.GetStringMapString("aliases")[strings.ToLower(name)]; alias != "" {
+		resolved = alias
+	} else {
+		if prefix := viper.GetString("table-prefix"); !strings.HasPrefix(resolved, prefix) {
+			resolved = prefix + resolved
+		}
+		if suffix := viper.GetString("table-suffix"); !strings.HasSuffix(resolved, suffix) {
+			resolved = resolved + suffix
+		}
+	}
+	if resolved != name {
+		logger.Debug(fmt.Sprintf("resolved table %s to %s", name, resolved))
+	}
+	return resolved
+}
+
+// configFilePath is the config file in use, or where a new one should be created
+func configFilePath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ddb.yaml"), nil
+}
+
+// configIndent returns the indentation of the first indented line of a yaml
+// file, so rewriting it keeps its formatting, or 2 if there is none
+func configIndent(contents []byte) int {
+	for line := range strings.Lines(string(contents)) {
+		content := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(content); indent > 0 && strings.TrimSpace(content) != "" && !strings.HasPrefix(content, "#") {
+			return indent
+		}
+	}
+	return 2
+}
+
+func encodeConfig(document *yaml.Node, indent int) ([]byte, error) {
+	var contents bytes.Buffer
+	encoder := yaml.NewEncoder(&contents)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("failed to marshal config file [%w]", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config file [%w]", err)
+	}
+	return contents.Bytes(), nil
+}
+
+// configValue converts the value of a setting to yaml, a list of patterns for
+// read-only-tables, a mapping for aliases and a scalar otherwise
+func configValue(key, value string) (*yaml.Node, error) {
+	switch key {
+	case "read-only-tables":
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for pattern := range strings.SplitSeq(strings.Trim(value, "[]"), ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: pattern})
+			}
+		}
+		return node, nil
+	case "aliases":
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for pair := range strings.SplitSeq(value, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			name, table, found := strings.Cut(pair, "=")
+			if name, table = strings.TrimSpace(name), strings.TrimSpace(table); !found || name == "" || table == "" {
+				return nil, fmt.Errorf("invalid alias %q, expected name=table", pair)
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &yaml.Node{Kind: yaml.ScalarNode, Value: table})
+		}
+		return node, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+}
+
+// setConfigValue sets a value at a path of mapping keys in a yaml document,
+// creating mappings as needed and keeping the rest of the document intact
+func setConfigValue(document *yaml.Node, path []string, value *yaml.Node) error {
+	if document.Kind == 0 {
+		*document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	node := document.Content[0]
+	for index, key := range path {
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			*node = yaml.Node{Kind: yaml.MappingNode}
+		}
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s in the config file is not a mapping", strings.Join(path[:index], "."))
+		}
+		var child *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				child = node.Content[i+1]
+				break
+			}
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		}
+		node = child
+	}
+	*node = *value
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+}
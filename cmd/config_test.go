@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetConfigValue(t *testing.T) {
+	var tests = []struct {
+		name, document, want string
+		valid                bool
+	}{
+		{"empty", "", "envs:\n  dev:\n    region: eu-west-1\n", true},
+		{"nullEnvs", "envs:\n", "envs:\n  dev:\n    region: eu-west-1\n", true},
+		{"newEnv", "pretty: false # keep\nenvs:\n  prod:\n    profile: prod\n", "pretty: false # keep\nenvs:\n  prod:\n    profile: prod\n  dev:\n    region: eu-west-1\n", true},
+		{"replace", "envs:\n  dev:\n    region: us-east-1\n", "envs:\n  dev:\n    region: eu-west-1\n", true},
+		{"fourSpaces", "# envs\nenvs:\n    prod:\n        profile: prod\n", "# envs\nenvs:\n    prod:\n        profile: prod\n    dev:\n        region: eu-west-1\n", true},
+		{"notMapping", "envs: [dev]\n", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(test.document), &document); err != nil {
+				t.Fatal(err)
+			}
+			err := setConfigValue(&document, []string{"envs", "dev", "region"}, &yaml.Node{Kind: yaml.ScalarNode, Value: "eu-west-1"})
+			if (err == nil) != test.valid {
+				t.Fatalf("got error %v want valid %t", err, test.valid)
+			}
+			if !test.valid {
+				return
+			}
+			contents, err := encodeConfig(&document, configIndent([]byte(test.document)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(contents) != test.want {
+				t.Errorf("got %q want %q", contents, test.want)
+			}
+		})
+	}
+}
+
+func TestConfigEnvironments(t *testing.T) {
+	fake := newOrdersFake(t)
+	configFile := filepath.Join(t.TempDir(), "ddb.yaml")
+	err := os.WriteFile(configFile, []byte("queries:\n  open-orders:\n    table: orders\n    index: byStatus\n    key: [open]\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "scan", "orders", "--config", configFile, "--env", "staging"); err == nil {
+		t.Errorf("got no error for an undefined environment")
+	}
+	// the environment can be created while it is selected
+	if _, err := runCommand(t, fake, "config", "set", "staging", "region", "eu-west-1", "--config", configFile, "--env", "staging"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "scan", "orders", "--config", configFile, "--env", "staging"); err != nil {
+		t.Errorf("got %v after defining the environment", err)
+	}
+	contents, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "queries:\n  open-orders:\n    table: orders\n    index: byStatus\n    key: [open]\nenvs:\n  staging:\n    region: eu-west-1\n"; string(contents) != want {
+		t.Errorf("got config file %q want %q", contents, want)
+	}
+
+	// patterns and aliases of an environment are lists and mappings
+	if _, err := runCommand(t, fake, "config", "set", "staging", "read-only-tables", "orders, *-prod-*", "--config", configFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "config", "set", "staging", "aliases", "o=orders,c=customers", "--config", configFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "config", "set", "staging", "aliases", "orders", "--config", configFile); err == nil {
+		t.Errorf("got no error for an alias without a table")
+	}
+	if contents, err = os.ReadFile(configFile); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  staging:\n    region: eu-west-1\n    read-only-tables: [orders, '*-prod-*']\n    aliases:\n      o: orders\n      c: customers\n"; !strings.HasSuffix(string(contents), want) {
+		t.Errorf("got config file %q want it to end with %q", contents, want)
+	}
+	if output, err := runCommand(t, fake, "scan", "o", "--config", configFile, "--env", "staging"); err != nil || strings.Count(output, "\n") != 3 {
+		t.Errorf("got %q, %v scanning orders through its alias", output, err)
+	}
+	if _, err := runCommand(t, fake, "table", "update", "o", "--deletion-protection", "--config", configFile, "--env", "staging"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("got %v writing to a read-only table", err)
+	}
+
+	if _, err := runCommand(t, fake, "config", "set", "Staging", "region", "eu-west-1", "--config", configFile); err == nil {
+		t.Errorf("got no error for an environment name in upper case")
+	}
+	output, err := runCommand(t, fake, "run", "Open-Orders", "--config", configFile, "--pretty=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(output, "\n") != 2 {
+		t.Errorf("got %q want the two open orders", output)
+	}
+}
+
+func TestResolveTableName(t *testing.T) {
+	setupLogger()
+	t.Cleanup(viper.Reset)
+	var tests = []struct {
+		name, prefix, suffix, table, want string
+	}{
+		{"unchanged", "", "", "orders", "orders"},
+		{"prefix", "acme-prod-", "", "orders", "acme-prod-orders"},
+		{"suffix", "", "-v3", "orders", "orders-v3"},
+		{"prefixAndSuffix", "acme-prod-", "-v3", "orders", "acme-prod-orders-v3"},
+		{"qualified", "acme-prod-", "-v3", "acme-prod-orders-v3", "acme-prod-orders-v3"},
+		{"alias", "acme-prod-", "-v3", "customers", "legacy-customers"},
+		{"aliasCase", "acme-prod-", "-v3", "Customers", "legacy-customers"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			viper.Set("table-prefix", test.prefix)
+			viper.Set("table-suffix", test.suffix)
+			viper.Set("aliases", map[string]string{"customers": "legacy-customers"})
+			if got := resolveTableName(test.table); got != test.want {
+				t.Errorf("got %s want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// explainedRequest is the part of a query or scan request explain shows
+type explainedRequest struct {
+	Operation                 string
+	TableName                 string
+	IndexName                 *string           `json:",omitempty"`
+	KeyConditionExpression    *string           `json:",omitempty"`
+	FilterExpression          *string           `json:",omitempty"`
+	ProjectionExpression      *string           `json:",omitempty"`
+	ExpressionAttributeNames  map[string]string `json:",omitempty"`
+	ExpressionAttributeValues map[string]any    `json:",omitempty"`
+}
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain <table[:index]> [partition] [sort]",
+	Short: "show the request of a query or scan",
+	Long: `Show the request a query, or a scan when no partition is given, would send
+without sending it, and how the table and its indexes could serve it better:
+filters that could be key conditions and indexes matching the filtered attributes.
+
+  ddb explain orders --filter status=open
+  ddb explain orders 123 --filter 'order>=2024'`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	tableArg, indexName, _ := strings.Cut(args[0], ":")
+	tableName := resolveTableName(tableArg)
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	paths := accessPaths(description)
+	path, found := findAccessPath(paths, indexName)
+	if !found {
+		return fmt.Errorf("table: %s doesn't have an index: %s", tableName, indexName)
+	}
+	filterArgs := viper.GetStringSlice("filter")
+	filters, err := parseConditions(filterArgs)
+	if err != nil {
+		return err
+	}
+
+	var request explainedRequest
+	var keyConditions []attributeCondition
+	if len(args) > 1 {
+		parsedArgs := ParseArgs(args)
+		queryInput, err := buildQueryInput(cmd.Context(), parsedArgs, filterArgs)
+		if err != nil {
+			return err
+		}
+		request = explainedRequest{
+			Operation:                 "Query",
+			TableName:                 tableName,
+			IndexName:                 queryInput.IndexName,
+			KeyConditionExpression:    queryInput.KeyConditionExpression,
+			FilterExpression:          queryInput.FilterExpression,
+			ProjectionExpression:      queryInput.ProjectionExpression,
+			ExpressionAttributeNames:  queryInput.ExpressionAttributeNames,
+			ExpressionAttributeValues: internal.WireItem(queryInput.ExpressionAttributeValues),
+		}
+		keyConditions = append(keyConditions, attributeCondition{path.keys[0].Name, parsedArgs.partitionValue, Equal})
+		if parsedArgs.sortValue != "" && len(path.keys) == 2 {
+			keyConditions = append(keyConditions, attributeCondition{path.keys[1].Name, parsedArgs.sortValue, parsedArgs.sortOperator})
+		}
+	} else {
+		scanInput, err := buildScanInput(tableName, filterArgs, nil)
+		if err != nil {
+			return err
+		}
+		if indexName != "" {
+			scanInput.IndexName = &indexName
+		}
+		request = explainedRequest{
+			Operation:                 "Scan",
+			TableName:                 tableName,
+			IndexName:                 scanInput.IndexName,
+			FilterExpression:          scanInput.FilterExpression,
+			ExpressionAttributeNames:  scanInput.ExpressionAttributeNames,
+			ExpressionAttributeValues: internal.WireItem(scanInput.ExpressionAttributeValues),
+		}
+	}
+	requestJson, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("Failed to Marshal request as json [%w]", err)
+	}
+	internal.PrintJson(requestJson, viper.GetBool("pretty"), viper.GetBool("color"))
+
+	for _, note := range explainNotes(tableArg, description, paths, path, keyConditions, filters) {
+		fmt.Println("- " + note)
+	}
+	return nil
+}
+
+// explainNotes describes what the request reads and how the conditions could
+// be served with more key conditions, keyConditions are the ones of the request
+func explainNotes(tableArg string, description *types.TableDescription, paths []accessPath, path accessPath, keyConditions, filters []attributeCondition) []string {
+	var notes []string
+	keyNames := strings.Join(path.keyNames(), ", ")
+	if len(keyConditions) == 0 {
+		notes = append(notes, fmt.Sprintf("scan reads every item of the %s (keys %s), filters reduce the items returned but not the capacity consumed", path, keyNames))
+	} else {
+		notes = append(notes, fmt.Sprintf("query reads one partition of the %s (keys %s)", path, keyNames))
+	}
+
+	conditions := slices.Concat(keyConditions, filters)
+	possible := matchPath(path, conditions)
+	if possible.score() > len(keyConditions) {
+		notes = append(notes, fmt.Sprintf("the filter could be a key condition of the %s: %s", path, possible.command(tableArg)))
+	}
+	best := bestMatch(paths, conditions)
+	if best.score() > max(possible.score(), len(keyConditions)) {
+		notes = append(notes, fmt.Sprintf("the %s matches the filtered attributes: %s", best.path, best.command(tableArg)))
+		var filterNames []string
+		for _, filter := range best.filters {
+			filterNames = append(filterNames, filter.name)
+		}
+		notes = append(notes, projectionNotes(description, best.path, filterNames)...)
+	}
+	if best.score() == 0 && len(filters) > 0 {
+		var equalities []string
+		for _, filter := range filters {
+			if filter.operator == Equal {
+				equalities = append(equalities, filter.name)
+			}
+		}
+		if len(equalities) > 0 {
+			suggestion := "partition key " + equalities[0]
+			for _, filter := range filters {
+				if filter.name != equalities[0] {
+					suggestion += " and sort key " + filter.name
+					break
+				}
+			}
+			notes = append(notes, fmt.Sprintf("no index can serve the filter, a global index with %s would turn the scan into a query", suggestion))
+		}
+	}
+	return notes
+}
+
+// projectionNotes warns about requested attributes an index doesn't project,
+// with no attributes requested it describes what the index projects
+func projectionNotes(description *types.TableDescription, path accessPath, attributes []string) []string {
+	if path.projection == nil || path.projection.ProjectionType == types.ProjectionTypeAll {
+		return nil
+	}
+	tableKeys := internal.SchemaKeys(description.KeySchema, description.AttributeDefinitions)
+	if len(attributes) == 0 {
+		projected := accessPath{keys: tableKeys}.keyNames()
+		for _, key := range path.keys {
+			if !slices.Contains(projected, key.Name) {
+				projected = append(projected, key.Name)
+			}
+		}
+		projected = append(projected, path.projection.NonKeyAttributes...)
+		return []string{fmt.Sprintf("the %s only projects %s", path, strings.Join(projected, ", "))}
+	}
+	var missing []string
+	for _, attribute := range attributes {
+		if !path.projects(attribute, tableKeys) {
+			missing = append(missing, attribute)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if path.local {
+		return []string{fmt.Sprintf("the %s doesn't project %s, they are fetched from the table at extra cost", path, strings.Join(missing, ", "))}
+	}
+	return []string{fmt.Sprintf("the %s doesn't project %s, items won't have them", path, strings.Join(missing, ", "))}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
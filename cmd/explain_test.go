@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var tests = []struct {
+		name  string
+		args  []string
+		notes []string
+	}{
+		{"scanWithIndex", []string{"explain", "orders", "--filter", "status=open", "--filter", "total>10"}, []string{
+			"- scan reads every item of the table (keys customer, order)",
+			"- the global index byStatus matches the filtered attributes: ddb query orders:byStatus open --filter 'total>10'",
+		}},
+		{"scanPartition", []string{"explain", "orders", "--filter", "customer=a"}, []string{
+			"- the filter could be a key condition of the table: ddb query orders a",
+		}},
+		{"querySort", []string{"explain", "orders", "a", "--filter", "order>=2"}, []string{
+			"- query reads one partition of the table (keys customer, order)",
+			"- the filter could be a key condition of the table: ddb query orders a '>=2'",
+		}},
+		{"noIndex", []string{"explain", "orders", "--filter", "total=5"}, []string{
+			"- no index can serve the filter, a global index with partition key total would turn the scan into a query",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := runCommand(t, newOrdersFake(t), append(test.args, "--pretty=false", "--color=false")...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, note := range test.notes {
+				if !strings.Contains(output, note) {
+					t.Errorf("got\n%s\nwant it to contain\n%s", output, note)
+				}
+			}
+		})
+	}
+}
+
+func TestExplainRequest(t *testing.T) {
+	fake := newOrdersFake(t)
+	output, err := runCommand(t, fake, "explain", "orders:byStatus", "open", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Operation":"Query","TableName":"orders","IndexName":"byStatus","KeyConditionExpression":"#0 = :0","ExpressionAttributeNames":{"#0":"status"},"ExpressionAttributeValues":{":0":{"S":"open"}}}`
+	if line, _, _ := strings.Cut(output, "\n"); line != want {
+		t.Errorf("got\n%s\nwant\n%s", line, want)
+	}
+}
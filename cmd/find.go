@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// findCmd represents the find command
+var findCmd = &cobra.Command{
+	Use:   "find <table> <attribute><op><value>...",
+	Short: "find items by attributes using the best index",
+	Long: `Find the items matching every condition, querying the table or the index whose
+keys match the most conditions. The partition key needs an = condition, the
+sort key any of =, <, <=, > and >=. The other conditions become filters.
+Without a matching index the table is scanned with a filter.
+
+  ddb find orders status=open 'created>=2024-01-01' --projection id,status`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runFind,
+}
+
+func runFind(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	conditions, err := parseConditions(args[1:])
+	if err != nil {
+		return err
+	}
+	projection, err := cmd.Flags().GetStringSlice("projection")
+	if err != nil {
+		return err
+	}
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+
+	match := bestMatch(accessPaths(description), conditions)
+	filterArgs := append(match.filterArgs(), viper.GetStringSlice("filter")...)
+	if match.score() == 0 {
+		logger.Warn(fmt.Sprintf("no index of %s has a partition key matching an = condition, scanning the table", tableName))
+		scanInput, err := buildScanInput(tableName, filterArgs, projection)
+		if err != nil {
+			return err
+		}
+		return printItems(internal.IterateScan(cmd.Context(), client, scanInput))
+	}
+
+	logger.Debug(fmt.Sprintf("querying the %s of %s", match.path, tableName))
+	var attributes []string
+	for _, attribute := range projection {
+		if !slices.Contains(attributes, attribute) {
+			attributes = append(attributes, attribute)
+		}
+	}
+	for _, filter := range match.filters {
+		if !slices.Contains(attributes, filter.name) {
+			attributes = append(attributes, filter.name)
+		}
+	}
+	for _, note := range projectionNotes(description, match.path, attributes) {
+		logger.Warn(note)
+	}
+	queryArgs := match.queryArgs(tableName)
+	queryArgs.projection = projection
+	queryInput, err := buildQueryInput(cmd.Context(), queryArgs, filterArgs)
+	if err != nil {
+		return err
+	}
+	return printItems(internal.IterateQuery(cmd.Context(), client, queryInput))
+}
+
+func init() {
+	rootCmd.AddCommand(findCmd)
+
+	findCmd.Flags().StringSlice("projection", []string{}, "attributes to return, by default all")
+}
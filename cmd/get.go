@@ -4,13 +4,9 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/dajmeister/ddb/internal"
 )
@@ -25,36 +21,22 @@ var getCmd = &cobra.Command{
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
-	tableName, partitionValue := args[0], args[1]
-	sortValue := ""
-	if len(args) == 3 {
-		sortValue = args[2]
-	}
+	tableName := resolveTableName(args[0])
 	logger.Debug(fmt.Sprintf("describing table %s", tableName))
-	keys, err := internal.GetTableKeys(client, tableName)
+	keys, err := internal.GetTableKeys(cmd.Context(), client, tableName)
 	if err != nil {
 		return fmt.Errorf("failed to get table keys: %w", err)
 	}
-	getKeys := make(map[string]types.AttributeValue)
-	partitionKey := keys[0] // partition key
-	partitionKeyValue, err := internal.MarshalArgument(partitionValue, partitionKey.AttributeType)
-	if err != nil {
-		return fmt.Errorf("failed to marshal argument 1 with value %s to type %s [%w]", partitionKeyValue, partitionKey.AttributeType, err)
+	keyArgs := args[1:]
+	if len(keys) == 1 {
+		keyArgs = keyArgs[:1] // a sort value is ignored for tables without a sort key
 	}
-	getKeys[partitionKey.Name] = partitionKeyValue
-	if len(keys) == 2 {
-		if len(args) != 3 {
-			return fmt.Errorf("get requires one argument per key, %d were provided. table %s has keys: %v", len(args)-1, tableName, keys)
-		}
-		sortKey := keys[1]
-		sortKeyValue, err := internal.MarshalArgument(sortValue, sortKey.AttributeType)
-		if err != nil {
-			return fmt.Errorf("failed to marshal argument 2 with value %s to type %s [%w]", sortKeyValue, sortKey.AttributeType, err)
-		}
-		getKeys[sortKey.Name] = sortKeyValue
+	getKeys, err := buildKeyValues(tableName, keys, keyArgs)
+	if err != nil {
+		return err
 	}
 	logger.Debug("running get")
-	item, err := internal.GetItem(client, tableName, getKeys)
+	item, err := internal.GetItem(cmd.Context(), client, tableName, getKeys)
 	if err != nil {
 		return fmt.Errorf("failed to get item: %w", err)
 	}
@@ -62,13 +44,25 @@ func runGet(cmd *cobra.Command, args []string) error {
 	if len(item) == 0 {
 		return nil
 	}
-	itemJson, err := json.Marshal(item)
-	if err != nil {
-		return fmt.Errorf("failed to marshal item as json: %w", err)
-	}
-	internal.PrintJson(itemJson, viper.GetBool("pretty"), viper.GetBool("color"))
+	return printRecords(func(yield func(map[string]any, error) bool) {
+		yield(item, nil)
+	})
+}
 
-	return nil
+// buildKeyValues marshals one argument per key to the key's attribute type
+func buildKeyValues(tableName string, keys []internal.Key, keyArgs []string) (internal.Item, error) {
+	if len(keyArgs) != len(keys) {
+		return nil, fmt.Errorf("one value per key is required, %d were provided. table %s has keys: %v", len(keyArgs), tableName, keys)
+	}
+	keyValues := make(internal.Item)
+	for index, key := range keys {
+		keyValue, err := internal.MarshalArgument(keyArgs[index], key.AttributeType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal argument %d with value %s to type %s [%w]", index+1, keyArgs[index], key.AttributeType, err)
+		}
+		keyValues[key.Name] = keyValue
+	}
+	return keyValues, nil
 }
 
 func init() {
@@ -0,0 +1,225 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// accessPath is a table or one of its indexes, a way items can be queried
+type accessPath struct {
+	indexName string // empty for the table itself
+	local     bool
+	keys      []internal.Key
+	// projection of an index, nil for the table which has every attribute
+	projection *types.Projection
+}
+
+func (path accessPath) String() string {
+	if path.indexName == "" {
+		return "table"
+	}
+	if path.local {
+		return "local index " + path.indexName
+	}
+	return "global index " + path.indexName
+}
+
+// target is the table argument of the query command for the path
+func (path accessPath) target(tableName string) string {
+	if path.indexName == "" {
+		return tableName
+	}
+	return tableName + ":" + path.indexName
+}
+
+func (path accessPath) keyNames() []string {
+	var names []string
+	for _, key := range path.keys {
+		names = append(names, key.Name)
+	}
+	return names
+}
+
+// accessPaths lists the table followed by its local and global indexes
+func accessPaths(description *types.TableDescription) []accessPath {
+	definitions := description.AttributeDefinitions
+	paths := []accessPath{{keys: internal.SchemaKeys(description.KeySchema, definitions)}}
+	for _, index := range description.LocalSecondaryIndexes {
+		paths = append(paths, accessPath{
+			indexName:  *index.IndexName,
+			local:      true,
+			keys:       internal.SchemaKeys(index.KeySchema, definitions),
+			projection: index.Projection,
+		})
+	}
+	for _, index := range description.GlobalSecondaryIndexes {
+		paths = append(paths, accessPath{
+			indexName:  *index.IndexName,
+			keys:       internal.SchemaKeys(index.KeySchema, definitions),
+			projection: index.Projection,
+		})
+	}
+	return paths
+}
+
+// findAccessPath returns the table, or the index with the given name
+func findAccessPath(paths []accessPath, indexName string) (accessPath, bool) {
+	for _, path := range paths {
+		if path.indexName == indexName {
+			return path, true
+		}
+	}
+	return accessPath{}, false
+}
+
+// projects reports whether items read through the path have the attribute.
+// Local indexes fetch missing attributes from the table at extra cost, global
+// indexes don't return them at all.
+func (path accessPath) projects(name string, tableKeys []internal.Key) bool {
+	if path.projection == nil || path.projection.ProjectionType == types.ProjectionTypeAll {
+		return true
+	}
+	for _, key := range slices.Concat(path.keys, tableKeys) {
+		if key.Name == name {
+			return true
+		}
+	}
+	return path.projection.ProjectionType == types.ProjectionTypeInclude &&
+		slices.Contains(path.projection.NonKeyAttributes, name)
+}
+
+// attributeCondition is a condition on a top level attribute given as name<op>value
+type attributeCondition struct {
+	name     string
+	value    string
+	operator Operator
+}
+
+func (condition attributeCondition) String() string {
+	return condition.name + string(condition.operator) + condition.value
+}
+
+func parseConditions(args []string) ([]attributeCondition, error) {
+	var conditions []attributeCondition
+	for _, arg := range args {
+		name, value, operator := ParseArg(arg)
+		if name == "" {
+			return nil, fmt.Errorf("condition %s must be of the form attribute<op>value", arg)
+		}
+		conditions = append(conditions, attributeCondition{name, value, operator})
+	}
+	return conditions, nil
+}
+
+// keyMatch is how an access path serves a set of conditions, the ones that
+// aren't key conditions are left as filters
+type keyMatch struct {
+	path      accessPath
+	partition *attributeCondition
+	sort      *attributeCondition
+	filters   []attributeCondition
+}
+
+// score counts the key conditions, a path without a partition condition can only be scanned
+func (match keyMatch) score() int {
+	switch {
+	case match.partition == nil:
+		return 0
+	case match.sort == nil:
+		return 1
+	}
+	return 2
+}
+
+func matchPath(path accessPath, conditions []attributeCondition) keyMatch {
+	match := keyMatch{path: path}
+	for _, condition := range conditions {
+		switch {
+		case match.partition == nil && condition.name == path.keys[0].Name && condition.operator == Equal:
+			match.partition = &condition
+		case match.sort == nil && len(path.keys) == 2 && condition.name == path.keys[1].Name:
+			match.sort = &condition
+		default:
+			match.filters = append(match.filters, condition)
+		}
+	}
+	if match.partition == nil && match.sort != nil {
+		// a sort key condition needs a partition condition
+		match.filters = append(match.filters, *match.sort)
+		match.sort = nil
+	}
+	return match
+}
+
+// bestMatch picks the path with the most key conditions, earlier paths win ties
+func bestMatch(paths []accessPath, conditions []attributeCondition) keyMatch {
+	best := matchPath(paths[0], conditions)
+	for _, path := range paths[1:] {
+		if match := matchPath(path, conditions); match.score() > best.score() {
+			best = match
+		}
+	}
+	return best
+}
+
+// queryArgs converts a match with a partition condition to the arguments of the query command
+func (match keyMatch) queryArgs(tableName string) queryArgs {
+	args := queryArgs{
+		tableName:      tableName,
+		indexName:      match.path.indexName,
+		partitionValue: match.partition.value,
+		sortOperator:   Equal,
+	}
+	if match.sort != nil {
+		args.sortValue = match.sort.value
+		args.sortOperator = match.sort.operator
+	}
+	return args
+}
+
+func (match keyMatch) filterArgs() []string {
+	var args []string
+	for _, filter := range match.filters {
+		args = append(args, filter.String())
+	}
+	return args
+}
+
+// command is the ddb command line reading the items of the match
+func (match keyMatch) command(tableName string) string {
+	var args []string
+	if match.partition == nil {
+		args = []string{"ddb", "scan", shellQuote(match.path.target(tableName))}
+	} else {
+		args = []string{"ddb", "query", shellQuote(match.path.target(tableName)), shellQuote(match.partition.value)}
+		if match.sort != nil {
+			sort := match.sort.value
+			if match.sort.operator != Equal {
+				sort = string(match.sort.operator) + sort
+			}
+			args = append(args, shellQuote(sort))
+		}
+	}
+	for _, filter := range match.filterArgs() {
+		args = append(args, "--filter", shellQuote(filter))
+	}
+	return strings.Join(args, " ")
+}
+
+var shellSafe = regexp.MustCompile(`^[\w.:/=@%+-]+$`)
+
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
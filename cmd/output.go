@@ -0,0 +1,244 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// printItems unmarshals and prints every item until the iterator is exhausted or fails
+func printItems(items iter.Seq2[internal.Item, error]) error {
+	return printRecords(internal.UnmarshalItems(items))
+}
+
+// printRecords prints every unmarshalled item or other record until the
+// iterator is exhausted or fails, ordered by --sort-by, transformed by --jq
+// and formatted by --template if given
+func printRecords(records iter.Seq2[map[string]any, error]) error {
+	printRecord, err := recordPrinter()
+	if err != nil {
+		return err
+	}
+	if sortBy := viper.GetStringSlice("sort-by"); len(sortBy) > 0 {
+		sorted, err := sortRecords(records, sortBy)
+		if err != nil {
+			return err
+		}
+		records = sorted
+	}
+	if expression := viper.GetString("jq"); expression != "" || viper.GetBool("slurp") {
+		return printJq(records, expression, viper.GetBool("slurp"), printRecord)
+	}
+	for item, err := range records {
+		if err != nil {
+			return err
+		}
+		if err := printRecord(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateEscapes are the escapes allowed in the text of --template, which is
+// hard to give tabs and newlines on the command line
+var templateEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n")
+
+// unescapeTemplate replaces the templateEscapes outside of actions, strings in
+// actions like {{printf "%s\n" .pk}} keep their go escapes
+func unescapeTemplate(text string) string {
+	var unescaped strings.Builder
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			unescaped.WriteString(templateEscapes.Replace(text))
+			return unescaped.String()
+		}
+		end := start + 2 + actionLength(text[start+2:])
+		unescaped.WriteString(templateEscapes.Replace(text[:start]))
+		unescaped.WriteString(text[start:end])
+		text = text[end:]
+	}
+}
+
+// actionLength returns the length of an action up to and including its }},
+// skipping strings, characters and comments which may contain }}
+func actionLength(action string) int {
+	for index := 0; index < len(action); index++ {
+		switch {
+		case strings.HasPrefix(action[index:], "}}"):
+			return index + 2
+		case strings.HasPrefix(action[index:], "/*"):
+			if end := strings.Index(action[index+2:], "*/"); end >= 0 {
+				index += 2 + end + 1
+			}
+		case action[index] == '`':
+			if end := strings.IndexByte(action[index+1:], '`'); end >= 0 {
+				index += 1 + end
+			}
+		case action[index] == '"' || action[index] == '\'':
+			for quote := action[index]; index+1 < len(action); {
+				index++
+				if action[index] == '\\' {
+					index++
+				} else if action[index] == quote {
+					break
+				}
+			}
+		}
+	}
+	return len(action)
+}
+
+// recordPrinter returns the function printing a record, as json or with the
+// --template or --template-file
+func recordPrinter() (func(any) error, error) {
+	text, file := viper.GetString("template"), viper.GetString("template-file")
+	if text != "" && file != "" {
+		return nil, fmt.Errorf("--template and --template-file can't be used together")
+	}
+	if text == "" && file == "" {
+		return printJson, nil
+	}
+	name := "template"
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file %s [%w]", file, err)
+		}
+		name, text = filepath.Base(file), string(content)
+	} else {
+		text = unescapeTemplate(text)
+	}
+	itemTemplate, err := internal.ParseItemTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+	return func(value any) error {
+		return itemTemplate.Execute(os.Stdout, value)
+	}, nil
+}
+
+// sortRecords reads every record and returns them ordered by the sort keys
+func sortRecords(records iter.Seq2[map[string]any, error], sortBy []string) (iter.Seq2[map[string]any, error], error) {
+	var keys []internal.SortKey
+	for _, arg := range sortBy {
+		key, err := internal.ParseSortKey(arg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	var all []map[string]any
+	for record, err := range records {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, record)
+	}
+	internal.SortRecords(all, keys)
+	return func(yield func(map[string]any, error) bool) {
+		for _, record := range all {
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// printJq prints the results of a jq expression applied to each record, or
+// with slurp to an array of all of them
+func printJq(records iter.Seq2[map[string]any, error], expression string, slurp bool, printRecord func(any) error) error {
+	if expression == "" {
+		expression = "."
+	}
+	query, err := internal.CompileJq(expression)
+	if err != nil {
+		return err
+	}
+	printResults := func(value any) error {
+		for result, err := range query.Run(value) {
+			if err != nil {
+				return err
+			}
+			if err := printRecord(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !slurp {
+		for record, err := range records {
+			if err != nil {
+				return err
+			}
+			if err := printResults(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	all := []any{}
+	for record, err := range records {
+		if err != nil {
+			return err
+		}
+		all = append(all, record)
+	}
+	return printResults(all)
+}
+
+// printDescriptions prints descriptions returned by the api as records, so
+// --sort-by, --jq and --template apply to them like to items
+func printDescriptions[T any](descriptions iter.Seq2[T, error]) error {
+	return printRecords(func(yield func(map[string]any, error) bool) {
+		for description, err := range descriptions {
+			var record map[string]any
+			if err == nil {
+				record, err = descriptionRecord(description)
+			}
+			if !yield(record, err) || err != nil {
+				return
+			}
+		}
+	})
+}
+
+func printDescription(description any) error {
+	return printDescriptions(func(yield func(any, error) bool) {
+		yield(description, nil)
+	})
+}
+
+func descriptionRecord(description any) (map[string]any, error) {
+	value, err := internal.JsonValue(description)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a json object", description)
+	}
+	return record, nil
+}
+
+// printJson prints a value, such as a description returned by the api, as json
+func printJson(value any) error {
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("Failed to Marshal %T as json [%w]", value, err)
+	}
+	internal.PrintJson(valueJson, viper.GetBool("pretty"), viper.GetBool("color"))
+	return nil
+}
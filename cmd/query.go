@@ -4,8 +4,9 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
 	"slices"
 	"strings"
 	"unicode"
@@ -36,6 +37,7 @@ type queryArgs struct {
 	partitionValue string
 	sortValue      string
 	sortOperator   Operator
+	projection     []string
 }
 
 // queryCmd represents the query command
@@ -81,7 +83,7 @@ func ParseArgs(args []string) queryArgs {
 		_, sort, prefix = ParseArg(args[2])
 	}
 	return queryArgs{
-		tableName:      table,
+		tableName:      resolveTableName(table),
 		indexName:      index,
 		partitionValue: partition,
 		sortValue:      sort,
@@ -115,38 +117,72 @@ func ParseArgValue(value string) (string, types.ScalarAttributeType) {
 
 		quotes := []rune{'"', '\''}
 		if first == last && slices.Contains(quotes, first) {
-			value = string(stringRunes[1 : valueLength-2])
+			value = string(stringRunes[1 : valueLength-1])
 		}
 	}
 
 	return value, types.ScalarAttributeTypeS
 }
 
-func runQuery(cmd *cobra.Command, raw_args []string) error {
-	args := ParseArgs(raw_args)
+func buildFilterCondition(filterArgs []string) (expression.ConditionBuilder, error) {
+	var filterConditions []expression.ConditionBuilder
+	for _, filterArg := range filterArgs {
+		field, value, operation := ParseArg(filterArg)
+		value, valueType := ParseArgValue(value)
+		filterValue, err := internal.MarshalArgument(value, valueType)
+		if err != nil {
+			return expression.ConditionBuilder{}, fmt.Errorf("failed to marshal filter %s with value %s to inferred type %s [%w]", filterArg, value, valueType, err)
+		}
+		filterExpression := expression.Name(field)
+		valueExpression := expression.Value(filterValue)
+		var filterCondition expression.ConditionBuilder
+		switch operation {
+		case Equal:
+			filterCondition = filterExpression.Equal(valueExpression)
+		case LessThan:
+			filterCondition = filterExpression.LessThan(valueExpression)
+		case LessThanEqual:
+			filterCondition = filterExpression.LessThanEqual(valueExpression)
+		case GreaterThan:
+			filterCondition = filterExpression.GreaterThan(valueExpression)
+		case GreaterThanEqual:
+			filterCondition = filterExpression.GreaterThanEqual(valueExpression)
+		}
+		filterConditions = append(filterConditions, filterCondition)
+	}
+	filterCondition := filterConditions[0]
+	for _, condition := range filterConditions[1:] {
+		filterCondition = filterCondition.And(condition)
+	}
+	return filterCondition, nil
+}
 
+func buildQueryInput(ctx context.Context, args queryArgs, filterArgs []string) (dynamodb.QueryInput, error) {
 	logger.Debug(fmt.Sprintf("describing table %s", args.tableName))
 	var keys []internal.Key
 	var err error
 	if args.indexName != "" {
-		keys, err = internal.GetIndexKeys(client, args.tableName, args.indexName)
+		keys, err = internal.GetIndexKeys(ctx, client, args.tableName, args.indexName)
 	} else {
-		keys, err = internal.GetTableKeys(client, args.tableName)
+		keys, err = internal.GetTableKeys(ctx, client, args.tableName)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to get keys: %w", err)
+		return dynamodb.QueryInput{}, fmt.Errorf("failed to get keys: %w", err)
 	}
 	partitionKey := keys[0] // partition key
 	partitionKeyValue, err := internal.MarshalArgument(args.partitionValue, partitionKey.AttributeType)
 	if err != nil {
-		return fmt.Errorf("failed to marshal argument 1 with value %s to type %s [%w]", partitionKeyValue, partitionKey.AttributeType, err)
+		return dynamodb.QueryInput{}, fmt.Errorf("failed to marshal argument 1 with value %s to type %s [%w]", partitionKeyValue, partitionKey.AttributeType, err)
 	}
 	keyCondition := expression.Key(partitionKey.Name).Equal(expression.Value(partitionKeyValue))
 	if args.sortValue != "" {
+		if len(keys) != 2 {
+			return dynamodb.QueryInput{}, fmt.Errorf("table %s has no sort key, keys: %v", args.tableName, keys)
+		}
 		sortKey := keys[1]
 		sortKeyValue, err := internal.MarshalArgument(args.sortValue, sortKey.AttributeType)
 		if err != nil {
-			return fmt.Errorf("failed to marshal argument 2 with value %s to type %s [%w]", sortKeyValue, sortKey.AttributeType, err)
+			return dynamodb.QueryInput{}, fmt.Errorf("failed to marshal argument 2 with value %s to type %s [%w]", sortKeyValue, sortKey.AttributeType, err)
 		}
 		sortKeyExpression := expression.Key(sortKey.Name)
 		sortValueExpression := expression.Value(sortKeyValue)
@@ -166,42 +202,19 @@ func runQuery(cmd *cobra.Command, raw_args []string) error {
 		keyCondition = keyCondition.And(sortKeyCondition)
 	}
 	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
-	filterArgs := viper.GetStringSlice("filter")
 	if len(filterArgs) > 0 {
-		var filterConditions []expression.ConditionBuilder
-		for _, filterArg := range filterArgs {
-			field, value, operation := ParseArg(filterArg)
-			value, valueType := ParseArgValue(value)
-			filterValue, err := internal.MarshalArgument(value, valueType)
-			if err != nil {
-				return fmt.Errorf("failed to marshal filter %s with value %s to inferred type %s [%w]", filterArg, value, valueType, err)
-			}
-			filterExpression := expression.Name(field)
-			valueExpression := expression.Value(filterValue)
-			var filterCondition expression.ConditionBuilder
-			switch operation {
-			case Equal:
-				filterCondition = filterExpression.Equal(valueExpression)
-			case LessThan:
-				filterCondition = filterExpression.LessThan(valueExpression)
-			case LessThanEqual:
-				filterCondition = filterExpression.LessThanEqual(valueExpression)
-			case GreaterThan:
-				filterCondition = filterExpression.GreaterThan(valueExpression)
-			case GreaterThanEqual:
-				filterCondition = filterExpression.GreaterThanEqual(valueExpression)
-			}
-			filterConditions = append(filterConditions, filterCondition)
-		}
-		filterCondition := filterConditions[0]
-		for _, condition := range filterConditions[1:] {
-			filterCondition = filterCondition.And(condition)
+		filterCondition, err := buildFilterCondition(filterArgs)
+		if err != nil {
+			return dynamodb.QueryInput{}, err
 		}
 		builder = builder.WithFilter(filterCondition)
 	}
+	if len(args.projection) > 0 {
+		builder = builder.WithProjection(buildProjection(args.projection))
+	}
 	expr, err := builder.Build()
 	if err != nil {
-		return fmt.Errorf("failed to build query expression [%w]", err)
+		return dynamodb.QueryInput{}, fmt.Errorf("failed to build query expression [%w]", err)
 	}
 	queryInput := dynamodb.QueryInput{
 		TableName:                 &args.tableName,
@@ -209,26 +222,69 @@ func runQuery(cmd *cobra.Command, raw_args []string) error {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
 	}
 	if args.indexName != "" {
 		queryInput.IndexName = &args.indexName
 	}
-	paginator := internal.IterateQuery(client, queryInput)
+	return queryInput, nil
+}
 
-	unmarshaller := internal.UnmarshalItems(paginator)
+func buildProjection(projection []string) expression.ProjectionBuilder {
+	var names []expression.NameBuilder
+	for _, name := range projection {
+		names = append(names, expression.Name(name))
+	}
+	return expression.NamesList(names[0], names[1:]...)
+}
 
-	for item, err := range unmarshaller {
-		if err != nil {
-			return err
-		}
-		itemJson, err := json.Marshal(item)
+func buildScanInput(tableName string, filterArgs []string, projection []string) (dynamodb.ScanInput, error) {
+	scanInput := dynamodb.ScanInput{
+		TableName: &tableName,
+	}
+	if len(filterArgs) == 0 && len(projection) == 0 {
+		return scanInput, nil
+	}
+	builder := expression.NewBuilder()
+	if len(filterArgs) > 0 {
+		filterCondition, err := buildFilterCondition(filterArgs)
 		if err != nil {
-			return fmt.Errorf("Failed to Marshal item as json [%w]", err)
+			return dynamodb.ScanInput{}, err
 		}
-		internal.PrintJson(itemJson, viper.GetBool("pretty"), viper.GetBool("color"))
+		builder = builder.WithFilter(filterCondition)
+	}
+	if len(projection) > 0 {
+		builder = builder.WithProjection(buildProjection(projection))
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return dynamodb.ScanInput{}, fmt.Errorf("failed to build scan expression [%w]", err)
+	}
+	scanInput.ExpressionAttributeNames = expr.Names()
+	scanInput.ExpressionAttributeValues = expr.Values()
+	scanInput.FilterExpression = expr.Filter()
+	scanInput.ProjectionExpression = expr.Projection()
+	return scanInput, nil
+}
+
+func runQuery(cmd *cobra.Command, raw_args []string) error {
+	paginator, err := queryItems(cmd, raw_args)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return printItems(paginator)
+}
+
+// queryItems queries the table or index of the arguments of query, applying --filter
+func queryItems(cmd *cobra.Command, raw_args []string) (iter.Seq2[internal.Item, error], error) {
+	args := ParseArgs(raw_args)
+
+	queryInput, err := buildQueryInput(cmd.Context(), args, viper.GetStringSlice("filter"))
+	if err != nil {
+		return nil, err
+	}
+	return internal.IterateQuery(cmd.Context(), client, queryInput), nil
 }
 
 func init() {
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func TestParseArg(t *testing.T) {
@@ -25,3 +27,29 @@ func TestParseArg(t *testing.T) {
 		})
 	}
 }
+
+func TestParseArgValue(t *testing.T) {
+	var tests = []struct {
+		name, arg, value string
+		valueType        types.ScalarAttributeType
+	}{
+		{"empty", "", "", types.ScalarAttributeTypeS},
+		{"string", "abc", "abc", types.ScalarAttributeTypeS},
+		{"integer", "123", "123", types.ScalarAttributeTypeN},
+		{"decimal", "123.0", "123.0", types.ScalarAttributeTypeN},
+		{"mixed", "abc123", "abc123", types.ScalarAttributeTypeS},
+		{"doubleQuoted", `"123.0"`, "123.0", types.ScalarAttributeTypeS},
+		{"singleQuoted", "'abc'", "abc", types.ScalarAttributeTypeS},
+		{"unbalancedQuotes", `"abc'`, `"abc'`, types.ScalarAttributeTypeS},
+		{"quotedEmpty", `""`, "", types.ScalarAttributeTypeS},
+		{"quotedCharacter", `'a'`, "a", types.ScalarAttributeTypeS},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, valueType := ParseArgValue(test.arg)
+			if value != test.value || valueType != test.valueType {
+				t.Errorf("got %s, %s want %s, %s", value, valueType, test.value, test.valueType)
+			}
+		})
+	}
+}
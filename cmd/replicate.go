@@ -0,0 +1,295 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// replicateCmd represents the replicate command
+var replicateCmd = &cobra.Command{
+	Use:   "replicate <table> --to <table|file>",
+	Short: "copy the changes of a table to another table or a change log",
+	Long: `Read the stream of a table and apply every change to a destination table,
+possibly in another region or endpoint, or append it to a change log file with
+one json change per line. Destinations ending in .ndjson, .jsonl or containing
+a path separator are files, anything else is a table.
+
+How far the stream was read is saved in a state file after each change, and
+replication resumes from there when run again. Changes are applied at least
+once: a change applied right before an interruption is applied again.
+Replicating to a table needs a stream with new images.
+
+  ddb replicate orders --to orders-copy --to-region eu-west-1
+  ddb replicate orders --to orders.ndjson --state orders.state.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplicate,
+}
+
+// replicationState is saved to the state file to resume replication
+type replicationState struct {
+	StreamArn  string              `json:"streamArn"`
+	Checkpoint internal.Checkpoint `json:"checkpoint"`
+}
+
+// changeApplier applies a change to the destination of a replication
+type changeApplier interface {
+	apply(ctx context.Context, record internal.ChangeRecord) error
+	Close() error
+}
+
+type tableApplier struct {
+	client    internal.DynamodbAPI
+	tableName string
+}
+
+type logApplier struct {
+	file *os.File
+}
+
+func runReplicate(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	destination, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return err
+	}
+	statePath, err := cmd.Flags().GetString("state")
+	if err != nil {
+		return err
+	}
+	if statePath == "" {
+		statePath = fmt.Sprintf("ddb-replicate-%s.json", tableName)
+	}
+	options, err := streamOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if err := setupStreamsClient(cmd.Context()); err != nil {
+		return err
+	}
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	streamArn, err := internal.StreamArn(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	state, err := readReplicationState(statePath, streamArn)
+	if err != nil {
+		return err
+	}
+	options.Checkpoint = &state.Checkpoint
+
+	applier, err := newChangeApplier(cmd, tableName, destination, description.StreamSpecification.StreamViewType)
+	if err != nil {
+		return err
+	}
+	defer applier.Close()
+
+	applied := 0
+	for record, err := range internal.ReadStream(cmd.Context(), streamsClient, streamArn, options) {
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				// the checkpoint only covers applied changes
+				break
+			}
+			return err
+		}
+		if err := applier.apply(cmd.Context(), record); err != nil {
+			return fmt.Errorf("failed to apply change %s, replication resumes from it [%w]", record.SequenceNumber, err)
+		}
+		if err := writeReplicationState(statePath, state); err != nil {
+			return err
+		}
+		applied++
+	}
+	if err := writeReplicationState(statePath, state); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("replicated %d changes of %s to %s", applied, tableName, destination))
+	return nil
+}
+
+// newChangeApplier opens the change log or a client for the destination table,
+// which can't be the source table
+func newChangeApplier(cmd *cobra.Command, sourceTableName, destination string, viewType types.StreamViewType) (changeApplier, error) {
+	if isChangeLog(destination) {
+		file, err := os.OpenFile(destination, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open change log: %w", err)
+		}
+		return &logApplier{file: file}, nil
+	}
+
+	if viewType != types.StreamViewTypeNewImage && viewType != types.StreamViewTypeNewAndOldImages {
+		return nil, fmt.Errorf("replicating to a table needs a stream with new images, the stream has %s", viewType)
+	}
+	tableName := resolveTableName(destination)
+	destinationClient, err := destinationClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if tableName == sourceTableName && destinationClient == client {
+		return nil, fmt.Errorf("can't replicate %s to itself, use --to-region, --to-endpoint-url, --to-profile or --to-role-arn for a table of the same name elsewhere", tableName)
+	}
+	if err := guardTableWrite(WriteAccess, "replicate to "+tableName, tableName); err != nil {
+		return nil, err
+	}
+	return &tableApplier{client: destinationClient, tableName: tableName}, nil
+}
+
+func isChangeLog(destination string) bool {
+	return strings.HasSuffix(destination, ".ndjson") || strings.HasSuffix(destination, ".jsonl") ||
+		strings.ContainsRune(destination, filepath.Separator) || strings.ContainsRune(destination, '/')
+}
+
+// destinationClient returns the client of the source table unless --to-region,
+// --to-endpoint-url, --to-profile or --to-role-arn is given, then a client
+// limited by --rate whose capacity --capacity reports like the one of the source
+func destinationClient(cmd *cobra.Command) (internal.DynamodbAPI, error) {
+	options, err := clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	for _, override := range []struct {
+		flag  string
+		value *string
+	}{
+		{"to-region", &options.Region},
+		{"to-endpoint-url", &options.EndpointUrl},
+		{"to-profile", &options.Profile},
+		{"to-role-arn", &options.RoleArn},
+	} {
+		if value, _ := cmd.Flags().GetString(override.flag); value != "" {
+			*override.value = value
+			changed = true
+		}
+	}
+	if !changed {
+		return client, nil
+	}
+	destinationClient, err := internal.DynamodbClient(cmd.Context(), options)
+	if err != nil {
+		return nil, err
+	}
+	limited := limitClient(destinationClient)
+	if capacityRecorder != nil {
+		return capacityRecorder.Wrap(limited), nil
+	}
+	return limited, nil
+}
+
+func (applier *tableApplier) apply(ctx context.Context, record internal.ChangeRecord) error {
+	if record.EventName == streamstypes.OperationTypeRemove {
+		return internal.DeleteItem(ctx, applier.client, applier.tableName, record.Keys)
+	}
+	return internal.PutItem(ctx, applier.client, applier.tableName, record.NewImage)
+}
+
+func (applier *tableApplier) Close() error {
+	return nil
+}
+
+// apply appends the change with its keys and images in the dynamodb json format
+func (applier *logApplier) apply(ctx context.Context, record internal.ChangeRecord) error {
+	change := map[string]any{
+		"event":          record.EventName,
+		"time":           record.Time.UTC().Format(time.RFC3339),
+		"sequenceNumber": record.SequenceNumber,
+		"keys":           internal.WireItem(record.Keys),
+	}
+	if record.OldImage != nil {
+		change["old"] = internal.WireItem(record.OldImage)
+	}
+	if record.NewImage != nil {
+		change["new"] = internal.WireItem(record.NewImage)
+	}
+	line, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change as json [%w]", err)
+	}
+	if _, err := applier.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write change log: %w", err)
+	}
+	return nil
+}
+
+func (applier *logApplier) Close() error {
+	return applier.file.Close()
+}
+
+// readReplicationState reads the state file, a missing file starts a new replication
+func readReplicationState(path, streamArn string) (*replicationState, error) {
+	state := &replicationState{StreamArn: streamArn}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(contents, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s [%w]", path, err)
+	}
+	if state.StreamArn != streamArn {
+		return nil, fmt.Errorf("state file %s is for stream %s, the table now has stream %s, remove it to start over", path, state.StreamArn, streamArn)
+	}
+	logger.Debug(fmt.Sprintf("resuming from %s", path))
+	return state, nil
+}
+
+// writeReplicationState replaces the state file at once, so an interruption leaves the previous state
+func writeReplicationState(path string, state *replicationState) error {
+	contents, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state [%w]", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(replicateCmd)
+
+	replicateCmd.Flags().String("to", "", "destination table, or change log file ending in .ndjson or .jsonl")
+	replicateCmd.MarkFlagRequired("to")
+	replicateCmd.Flags().String("state", "", "state file to resume from (default ddb-replicate-<table>.json)")
+	replicateCmd.Flags().String("from", "trim-horizon", "where to start reading shards without a checkpoint, latest or trim-horizon (the last 24 hours)")
+	replicateCmd.Flags().Bool("follow", true, "keep waiting for changes, otherwise stop at the end of the stream")
+	replicateCmd.Flags().Duration("poll-interval", time.Second, "pause between reads once the end of the stream is reached")
+	replicateCmd.Flags().String("to-region", "", "region of the destination table (default the region of the source)")
+	replicateCmd.Flags().String("to-endpoint-url", "", "endpoint of the destination table (default the endpoint of the source)")
+	replicateCmd.Flags().String("to-profile", "", "aws profile for the destination table (default the profile of the source)")
+	replicateCmd.Flags().String("to-role-arn", "", "iam role to assume for the destination table")
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestReplicateToTable(t *testing.T) {
+	fake := newOrdersFake(t)
+	enableStream(t, fake)
+	if _, err := runCommand(t, fake, "table", "create", "orders-copy", "--like", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if _, err := runCommand(t, fake, "replicate", "orders", "--to", "orders-copy", "--state", statePath, "--follow=false"); err != nil {
+		t.Fatal(err)
+	}
+	count := func() int {
+		output, err := fake.Scan(context.TODO(), &dynamodb.ScanInput{TableName: aws.String("orders-copy")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(output.Items)
+	}
+	// the stream starts after the orders were put, it holds a change of a/1 and the removal of b/3
+	if got := count(); got != 1 {
+		t.Errorf("got %d items, want 1", got)
+	}
+
+	item, err := internal.MarshalItem(map[string]any{"customer": "c", "order": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.PutItem(context.TODO(), fake, "orders", item); err != nil {
+		t.Fatal(err)
+	}
+	replicated, err := internal.MarshalItem(map[string]any{"customer": "a", "order": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.DeleteItem(context.TODO(), fake, "orders-copy", replicated); err != nil {
+		t.Fatal(err)
+	}
+	// resuming applies the new change only, a replay would bring back a/1 too
+	if _, err := runCommand(t, fake, "replicate", "orders", "--to", "orders-copy", "--state", statePath, "--follow=false"); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 1 {
+		t.Errorf("got %d items after resuming, want 1", got)
+	}
+
+	if _, err := runCommand(t, fake, "replicate", "orders", "--to", "orders", "--state", statePath, "--follow=false"); err == nil {
+		t.Errorf("got no error replicating a table to itself")
+	}
+}
+
+func TestReplicateToChangeLog(t *testing.T) {
+	fake := newOrdersFake(t)
+	enableStream(t, fake)
+	directory := t.TempDir()
+	changeLog := filepath.Join(directory, "orders.ndjson")
+	statePath := filepath.Join(directory, "state.json")
+	for range 2 {
+		if _, err := runCommand(t, fake, "replicate", "orders", "--to", changeLog, "--state", statePath, "--follow=false"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contents, err := os.ReadFile(changeLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"new":{"customer":{"S":"a"}`) || !strings.Contains(lines[1], `"event":"REMOVE"`) {
+		t.Errorf("got change log\n%s", contents)
+	}
+
+	if err := os.WriteFile(statePath, []byte(`{"streamArn": "other"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "replicate", "orders", "--to", changeLog, "--state", statePath); err == nil {
+		t.Errorf("got no error resuming from the state of another stream")
+	}
+}
+
+func TestDestinationClient(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() {
+		viper.Reset()
+		resetFlags(rootCmd)
+		capacityRecorder = nil
+	})
+	viper.Set("rate", 5.0)
+	capacityRecorder = internal.NewCapacityRecorder(newOrdersFake(t))
+	if err := replicateCmd.Flags().Set("to-region", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	replicateCmd.SetContext(context.Background())
+	destination, err := destinationClient(replicateCmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder, ok := destination.(*internal.CapacityRecorder)
+	if !ok {
+		t.Fatalf("got a %T for another region, want its capacity recorded", destination)
+	}
+	if _, ok := recorder.DynamodbAPI.(*internal.RateLimitedClient); !ok {
+		t.Errorf("got a %T for another region, want it limited by --rate", recorder.DynamodbAPI)
+	}
+
+	// the usage of a wrapped client is added up with the one of the source
+	for _, client := range []internal.DynamodbAPI{capacityRecorder, capacityRecorder.Wrap(newOrdersFake(t))} {
+		for _, err := range internal.IterateScan(context.TODO(), client, dynamodb.ScanInput{TableName: aws.String("orders")}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if capacityRecorder.Requests != 2 || capacityRecorder.Usage["orders"] == nil {
+		t.Errorf("got %d requests using %+v, want the scans of both clients", capacityRecorder.Requests, capacityRecorder.Usage)
+	}
+}
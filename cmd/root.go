@@ -4,15 +4,20 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"golang.org/x/term"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/dajmeister/ddb/internal"
@@ -20,7 +25,14 @@ import (
 
 var cfgFile string
 var logger *slog.Logger
-var client *dynamodb.Client
+var client internal.DynamodbAPI
+
+// streamsClient reads table streams, it is created by the commands using it
+var streamsClient internal.StreamsAPI
+var cancelTimeout context.CancelFunc = func() {}
+
+// capacityRecorder is set by --capacity to report the capacity used by the command
+var capacityRecorder *internal.CapacityRecorder
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -35,23 +47,131 @@ to quickly create a Cobra application.`,
 	SilenceUsage: true, // don't print usage if a subcommand fails
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		setupLogger()
-		var err error
-		client, err = internal.DynamodbClient()
-		if err != nil {
-			logger.Error("failed to create dynamodb client")
-			return err
+		if err := applyEnvironment(viper.GetString("env"), cmd.Root().PersistentFlags()); err != nil {
+			// the config commands create and fix environments
+			if cmd.Parent() != configCmd {
+				return err
+			}
+			logger.Warn(err.Error())
+		}
+		if timeout := viper.GetDuration("timeout"); timeout > 0 {
+			var ctx context.Context
+			ctx, cancelTimeout = context.WithTimeout(cmd.Context(), timeout)
+			cmd.SetContext(ctx)
+		}
+		if client == nil { // unless already set, e.g. to a fake in tests
+			options, err := clientOptions()
+			if err != nil {
+				return err
+			}
+			client, err = internal.DynamodbClient(cmd.Context(), options)
+			if err != nil {
+				logger.Error("failed to create dynamodb client")
+				return err
+			}
+		}
+		client = limitClient(client)
+		if viper.GetBool("capacity") {
+			capacityRecorder = internal.NewCapacityRecorder(client)
+			client = capacityRecorder
 		}
 		return nil
 	},
 }
 
+// limitClient applies --rate to a client, every client writing or reading
+// items goes through it
+func limitClient(client internal.DynamodbAPI) internal.DynamodbAPI {
+	if rate := viper.GetFloat64("rate"); rate > 0 {
+		return internal.NewRateLimitedClient(client, rate)
+	}
+	return client
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// interrupting cancels in-flight requests, items already printed are kept
+	ctx, stop := notifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	cancelTimeout()
+	if capacityRecorder != nil { // also after failures, partial scans cost too
+		capacityRecorder.Report(os.Stderr)
+	}
+	if code := exitCode(ctx, err); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// signalError is the cause of the cancellation of the context of a command by a signal
+type signalError struct {
+	signal os.Signal
+}
+
+func (err signalError) Error() string {
+	return fmt.Sprintf("received %s", err.signal)
+}
+
+// notifyContext is like signal.NotifyContext, but records the signal as the
+// cause of the cancellation, stopping isn't mistaken for a signal
+func notifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-received:
+			cancel(signalError{signal: sig})
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(done)
+			cancel(context.Canceled)
+		})
+	}
+}
+
+// exitCode is 128 plus the number of the signal which interrupted the
+// command, e.g. 130 for SIGINT and 143 for SIGTERM, 1 if it failed, else 0
+func exitCode(ctx context.Context, err error) int {
+	var interrupted signalError
+	if errors.As(context.Cause(ctx), &interrupted) {
+		if number, ok := interrupted.signal.(syscall.Signal); ok {
+			return 128 + int(number)
+		}
+		return 130
+	}
 	if err != nil {
-		os.Exit(1)
+		return 1
 	}
+	return 0
+}
+
+func clientOptions() (internal.ClientOptions, error) {
+	var retryMode aws.RetryMode
+	if mode := viper.GetString("retry-mode"); mode != "" {
+		var err error
+		if retryMode, err = aws.ParseRetryMode(mode); err != nil {
+			return internal.ClientOptions{}, err
+		}
+	}
+	return internal.ClientOptions{
+		EndpointUrl: viper.GetString("endpoint-url"),
+		Region:      viper.GetString("region"),
+		Profile:     viper.GetString("profile"),
+		RoleArn:     viper.GetString("role-arn"),
+
+		RequestTimeout: viper.GetDuration("request-timeout"),
+		RetryMode:      retryMode,
+		MaxAttempts:    viper.GetInt("max-attempts"),
+		MaxBackoff:     viper.GetDuration("max-backoff"),
+	}, nil
 }
 
 func setupLogger() {
@@ -66,10 +186,29 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ddb.yaml)")
+	rootCmd.PersistentFlags().String("env", "", "named environment from the config file (default $DDB_ENV)")
 	rootCmd.PersistentFlags().BoolP("pretty", "p", true, "pretty print items")
 	rootCmd.PersistentFlags().Bool("color", true, "don't color output")
+	rootCmd.PersistentFlags().StringSlice("sort-by", []string{}, "order the items printed by fields, e.g. total:desc,order (reads every item first)")
+	rootCmd.PersistentFlags().String("jq", "", "jq expression applied to each item printed, e.g. '{id, total}'")
+	rootCmd.PersistentFlags().Bool("slurp", false, "apply --jq to an array of all the items instead of each one")
+	rootCmd.PersistentFlags().String("template", "", "go template printing each item, with the functions date, dateMillis, json, default, truncate and join, e.g. '{{.pk}}\\t{{date \"2006-01-02\" .expires}}'")
+	rootCmd.PersistentFlags().String("template-file", "", "file with a go template printing each item")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringSliceP("filter", "f", []string{}, "filters to apply to the operation")
+	rootCmd.PersistentFlags().String("endpoint-url", "", "dynamodb endpoint, e.g. http://localhost:8000 for DynamoDB Local")
+	rootCmd.PersistentFlags().String("region", "", "aws region (default from the aws config)")
+	rootCmd.PersistentFlags().String("profile", "", "aws shared config profile")
+	rootCmd.PersistentFlags().String("role-arn", "", "iam role to assume")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "time limit for the whole command, e.g. 30s (default no limit)")
+	rootCmd.PersistentFlags().Duration("request-timeout", 0, "time limit for each request to dynamodb (default no limit)")
+	rootCmd.PersistentFlags().String("retry-mode", "", "retry mode, standard or adaptive (default from the aws config)")
+	rootCmd.PersistentFlags().Int("max-attempts", 0, "maximum attempts of each request including retries (default from the aws config)")
+	rootCmd.PersistentFlags().Duration("max-backoff", 0, "maximum delay between retries (default 20s)")
+	rootCmd.PersistentFlags().Float64("rate", 0, "limit read and write capacity units used per second (default no limit)")
+	rootCmd.PersistentFlags().Bool("capacity", false, "report the consumed capacity and estimated cost on stderr")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "don't ask for confirmation of writes")
+	rootCmd.PersistentFlags().Bool("force", false, "allow destructive operations without a terminal on stdin")
 }
 
 func initConfig() {
@@ -95,10 +234,32 @@ func initConfig() {
 		viper.SetDefault("color", false)
 	}
 
+	// writes affecting more items, or sql write statements, than this need confirmation
+	viper.SetDefault("confirm-threshold", 1)
+
 	viper.AutomaticEnv() // read in environment variables that match
+	viper.BindEnv("env", "DDB_ENV")
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// applyEnvironment overrides settings with those of the named environment,
+// flags given on the command line still take precedence
+func applyEnvironment(env string, flags *pflag.FlagSet) error {
+	if env == "" {
+		return nil
+	}
+	if !viper.IsSet("envs." + env) {
+		return fmt.Errorf("environment %s is not defined in the config file", env)
+	}
+	for key, value := range viper.GetStringMap("envs." + env) {
+		if flag := flags.Lookup(key); flag != nil && flag.Changed {
+			continue
+		}
+		viper.Set(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// newOrdersFake returns a fake with an orders table keyed by customer and
+// order number, with a byStatus index, holding three orders
+func newOrdersFake(t *testing.T) *internal.FakeDynamodb {
+	t.Helper()
+	fake := internal.NewFakeDynamodb()
+	_, err := fake.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
+		TableName: aws.String("orders"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeN},
+			{AttributeName: aws.String("status"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("customer"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("order"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName: aws.String("byStatus"),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("status"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("order"), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range []map[string]any{
+		{"customer": "a", "order": 1, "status": "open", "total": 5},
+		{"customer": "a", "order": 2, "status": "shipped", "total": 20},
+		{"customer": "b", "order": 3, "status": "open", "total": 30},
+	} {
+		item, err := internal.MarshalItem(order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := internal.PutItem(context.TODO(), fake, "orders", item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fake
+}
+
+// runCommand executes ddb against a fake and returns what it printed to stdout
+func runCommand(t *testing.T, fake internal.DynamodbAPI, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	client = fake
+	streamsClient, _ = fake.(internal.StreamsAPI)
+	t.Cleanup(func() {
+		client = nil
+		streamsClient = nil
+		capacityRecorder = nil
+		viper.Reset()
+		resetFlags(rootCmd)
+	})
+
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+	// start from the defaults, earlier executions in the same test leave flags set
+	resetFlags(rootCmd)
+	viper.Reset()
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	writer.Close()
+	os.Stdout = stdout
+	output, _ := io.ReadAll(reader)
+	return string(output), err
+}
+
+// resetFlags restores the defaults of every flag, cobra keeps values between executions
+func resetFlags(command *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			sliceValue.Replace(nil)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	command.Flags().VisitAll(reset)
+	command.PersistentFlags().VisitAll(reset)
+	for _, child := range command.Commands() {
+		resetFlags(child)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	var tests = []struct {
+		name   string
+		args   []string
+		output string
+	}{
+		{"get", []string{"get", "orders", "a", "2"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}`},
+		{"getMissing", []string{"get", "orders", "a", "9"}, ``},
+		{"query", []string{"query", "orders", "a"},
+			`{"customer":"a","order":1,"status":"open","total":5}
+{"customer":"a","order":2,"status":"shipped","total":20}`},
+		{"querySort", []string{"query", "orders", "a", ">1"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}`},
+		{"queryFilter", []string{"query", "orders", "a", "--filter", "total>=10"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}`},
+		{"queryIndex", []string{"query", "orders:byStatus", "open"},
+			`{"customer":"a","order":1,"status":"open","total":5}
+{"customer":"b","order":3,"status":"open","total":30}`},
+		{"scan", []string{"scan", "orders"},
+			`{"customer":"a","order":1,"status":"open","total":5}
+{"customer":"a","order":2,"status":"shipped","total":20}
+{"customer":"b","order":3,"status":"open","total":30}`},
+		{"findTable", []string{"find", "orders", "customer=a", "order>1"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}`},
+		{"findIndex", []string{"find", "orders", "total>10", "status=open"},
+			`{"customer":"b","order":3,"status":"open","total":30}`},
+		{"findScan", []string{"find", "orders", "total=20", "--projection", "order,total"},
+			`{"order":2,"total":20}`},
+		{"scanJq", []string{"scan", "orders", "--jq", "select(.total > 10) | {order, due: (.total * 2)}"},
+			`{"due":40,"order":2}
+{"due":60,"order":3}`},
+		{"scanJqSlurp", []string{"scan", "orders", "--jq", "map(.total) | add", "--slurp"},
+			`55`},
+		{"getJq", []string{"get", "orders", "a", "2", "--jq", ".status"},
+			`"shipped"`},
+		{"scanSortBy", []string{"scan", "orders", "--sort-by", "status:desc,total:desc"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}
+{"customer":"b","order":3,"status":"open","total":30}
+{"customer":"a","order":1,"status":"open","total":5}`},
+		{"aggScan", []string{"agg", "scan", "orders", "--group-by", "status", "--count", "--sum", "total", "--max", "customer"},
+			`{"count":2,"max_customer":"b","status":"open","sum_total":35}
+{"count":1,"max_customer":"a","status":"shipped","sum_total":20}`},
+		{"scanFilter", []string{"scan", "orders", "--filter", "total>10"},
+			`{"customer":"a","order":2,"status":"shipped","total":20}
+{"customer":"b","order":3,"status":"open","total":30}`},
+		{"aggScanFilter", []string{"agg", "scan", "orders", "--filter", "total>10", "--sum", "total"},
+			`{"sum_total":50}`},
+		{"aggQuery", []string{"agg", "query", "orders", "a", "--avg", "total", "--min", "order"},
+			`{"avg_total":12.5,"min_order":1}`},
+		{"aggSortBy", []string{"agg", "scan", "orders", "--group-by", "customer", "--sort-by", "count:desc"},
+			`{"count":2,"customer":"a"}
+{"count":1,"customer":"b"}`},
+		{"scanTemplate", []string{"scan", "orders", "--template", `{{.customer}}\t{{.order}}{{if gt .total 10}}\tlarge{{end}}`},
+			"a\t1\na\t2\tlarge\nb\t3\tlarge"},
+		{"scanTemplateActionEscapes", []string{"scan", "orders", "--template", `{{printf "%s\t%v\n" .customer .order}}{{/* "}}" */}}{{"\\n"}}\n`},
+			"a\t1\n\\n\na\t2\n\\n\nb\t3\n\\n"},
+		{"tableDescribeTemplate", []string{"table", "describe", "orders", "--template", `{{.TableName}} {{len .GlobalSecondaryIndexes}}`},
+			`orders 1`},
+		{"aggJqTemplate", []string{"agg", "scan", "orders", "--group-by", "status", "--jq", "select(.count > 1)", "--template", `{{.status}}: {{.count}}`},
+			`open: 2`},
+		{"scanRateLimited", []string{"scan", "orders", "--rate", "5"},
+			`{"customer":"a","order":1,"status":"open","total":5}
+{"customer":"a","order":2,"status":"shipped","total":20}
+{"customer":"b","order":3,"status":"open","total":30}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newOrdersFake(t)
+			fake.PageSize = 1
+			output, err := runCommand(t, fake, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output = strings.TrimSpace(output); output != test.output {
+				t.Errorf("got\n%s\nwant\n%s", output, test.output)
+			}
+		})
+	}
+}
+
+func TestTemplateFile(t *testing.T) {
+	fake := newOrdersFake(t)
+	templateFile := filepath.Join(t.TempDir(), "get.tmpl")
+	if err := os.WriteFile(templateFile, []byte(`ddb get orders {{join " " .customer .order}} # {{default "-" .note}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output, err := runCommand(t, fake, "query", "orders", "a", "--template-file", templateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ddb get orders a 1 # -\nddb get orders a 2 # -\n"; output != want {
+		t.Errorf("got %q want %q", output, want)
+	}
+	if _, err := runCommand(t, fake, "scan", "orders", "--template-file", templateFile, "--template", "{{.}}"); err == nil {
+		t.Errorf("got no error for both a template and a template file")
+	}
+	if _, err := runCommand(t, fake, "scan", "orders", "--template", "{{.customer"); err == nil {
+		t.Errorf("got no error for an invalid template")
+	}
+}
+
+func TestTransact(t *testing.T) {
+	fake := newOrdersFake(t)
+	transactFile := filepath.Join(t.TempDir(), "transact.yaml")
+	err := os.WriteFile(transactFile, []byte(`
+- {op: put, table: orders, item: {customer: b, order: 4, status: open}}
+- {op: update, table: orders, key: [a, 1], set: [status=shipped], condition: [status=open]}
+- {op: delete, table: orders, key: [b, 3]}
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "transact", transactFile, "--yes", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	output, err := runCommand(t, fake, "scan", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"customer":"a","order":1,"status":"shipped","total":5}
+{"customer":"a","order":2,"status":"shipped","total":20}
+{"customer":"b","order":4,"status":"open"}`
+	if output = strings.TrimSpace(output); output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+
+	// the condition on the update no longer holds so nothing is written
+	if _, err := runCommand(t, fake, "transact", transactFile, "--yes", "--force"); err == nil {
+		t.Errorf("got no error for a canceled transaction")
+	}
+	output, err = runCommand(t, fake, "get", "orders", "b", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output = strings.TrimSpace(output); output != `{"customer":"b","order":4,"status":"open"}` {
+		t.Errorf("got %s after a canceled transaction", output)
+	}
+	if err := os.WriteFile(transactFile, []byte("- {op: delete, key: [a, 1]}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "transact", transactFile, "--yes", "--force"); err == nil || !strings.Contains(err.Error(), "table is required") {
+		t.Errorf("got %v want an error for the missing table", err)
+	}
+}
+
+func TestGetWithoutSortKey(t *testing.T) {
+	fake := internal.NewFakeDynamodb()
+	_, err := fake.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
+		TableName:            aws.String("customers"),
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("customer"), KeyType: types.KeyTypeHash}},
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := internal.MarshalItem(map[string]any{"customer": "a", "name": "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.PutItem(context.TODO(), fake, "customers", item); err != nil {
+		t.Fatal(err)
+	}
+	// a sort value is ignored for tables without a sort key, as it always was
+	for _, args := range [][]string{{"get", "customers", "a"}, {"get", "customers", "a", "1"}} {
+		output, err := runCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output = strings.TrimSpace(output); output != `{"customer":"a","name":"Ann"}` {
+			t.Errorf("got %s for %v", output, args)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	fake := newOrdersFake(t)
+	if _, err := runCommand(t, fake, "query", "orders:byStatus", "open", "--filter", "total>10", "--capacity"); err != nil {
+		t.Fatal(err)
+	}
+	recorder := capacityRecorder
+	if recorder.Requests != 1 || recorder.Scanned != 2 || recorder.Returned != 1 {
+		t.Errorf("got %d requests scanning %d items returning %d", recorder.Requests, recorder.Scanned, recorder.Returned)
+	}
+	if usage := recorder.Usage["orders:byStatus"]; usage == nil || usage.ReadUnits != 1 {
+		t.Errorf("got index usage %+v, want 1 read unit", usage)
+	}
+	if recorder.Total.ReadUnits != 1 || recorder.Cost() <= 0 {
+		t.Errorf("got total %+v costing %g", recorder.Total, recorder.Cost())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	// stopping without a signal, as after every command, isn't an interrupt
+	ctx, stop := notifyContext(context.Background(), syscall.SIGTERM)
+	stop()
+	if code := exitCode(ctx, nil); code != 0 {
+		t.Errorf("got exit code %d after a successful command", code)
+	}
+	if code := exitCode(ctx, errors.New("failed")); code != 1 {
+		t.Errorf("got exit code %d after a failed command", code)
+	}
+
+	ctx, stop = notifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	<-ctx.Done()
+	stop()
+	if code := exitCode(ctx, context.Canceled); code != 143 {
+		t.Errorf("got exit code %d after SIGTERM, want 143", code)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(signalError{signal: os.Interrupt})
+	if code := exitCode(ctx, nil); code != 130 {
+		t.Errorf("got exit code %d after an interrupt, want 130", code)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+type savedQuery struct {
+	Description string   `mapstructure:"description"`
+	Table       string   `mapstructure:"table"`
+	Index       string   `mapstructure:"index"`
+	Key         []string `mapstructure:"key"`
+	Filters     []string `mapstructure:"filters"`
+	Projection  []string `mapstructure:"projection"`
+	Pretty      *bool    `mapstructure:"pretty"`
+	SortBy      []string `mapstructure:"sort-by"`
+	Jq          string   `mapstructure:"jq"`
+	Template    string   `mapstructure:"template"`
+}
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "run a saved query",
+	Long: `Run a query saved in the config file under queries.<name>.
+
+The key holds the partition and optional sort argument of the query command,
+filters use the filter syntax and {{placeholders}} anywhere in them are
+replaced with --param values, e.g.
+
+  queries:
+    customer-orders:
+      description: orders of a customer since a date
+      table: orders
+      index: byCustomer
+      key: ["{{customer}}", ">={{since}}"]
+      filters: ["status={{status}}"]
+      projection: [pk, sk, status]
+      pretty: false
+      sort-by: [total:desc]
+      jq: "{sk, status}"
+
+  ddb run customer-orders --param customer=123 --param since=2024 --param status=open
+
+The output settings pretty, sort-by, jq and template are those of the flags of
+the same name, which take precedence when given. Other settings are rejected.
+Names of saved queries are case insensitive, like all keys of the config file.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runRun,
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	var queries map[string]savedQuery
+	if err := viper.UnmarshalKey("queries", &queries); err != nil {
+		return fmt.Errorf("failed to read saved queries [%w]", err)
+	}
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		listSavedQueries(queries)
+		return nil
+	}
+
+	name := args[0]
+	query, found := queries[strings.ToLower(name)] // viper reads keys in lower case
+	if !found {
+		return fmt.Errorf("no saved query named %s, see ddb run --list", name)
+	}
+	// a misspelt setting would otherwise be silently ignored
+	rejectUnknown := func(config *mapstructure.DecoderConfig) { config.ErrorUnused = true }
+	if err := viper.UnmarshalKey("queries."+strings.ToLower(name), &query, rejectUnknown); err != nil {
+		return fmt.Errorf("saved query %s has invalid settings [%w]", name, err)
+	}
+	paramArgs, err := cmd.Flags().GetStringArray("param")
+	if err != nil {
+		return err
+	}
+	params := make(map[string]string)
+	for _, paramArg := range paramArgs {
+		key, value, found := strings.Cut(paramArg, "=")
+		if !found {
+			return fmt.Errorf("param %s must be of the form name=value", paramArg)
+		}
+		params[key] = value
+	}
+
+	if query.Table == "" || len(query.Key) < 1 || len(query.Key) > 2 {
+		return fmt.Errorf("saved query %s needs a table and a key of one or two values", name)
+	}
+	table := query.Table
+	if query.Index != "" {
+		table += ":" + query.Index
+	}
+	rawArgs, err := expandPlaceholders(append([]string{table}, query.Key...), params)
+	if err != nil {
+		return fmt.Errorf("saved query %s: %w", name, err)
+	}
+	filterArgs, err := expandPlaceholders(query.Filters, params)
+	if err != nil {
+		return fmt.Errorf("saved query %s: %w", name, err)
+	}
+	for key := range params {
+		if !slices.Contains(queryParameters(query), key) {
+			logger.Warn(fmt.Sprintf("saved query %s doesn't use param %s", name, key))
+		}
+	}
+
+	parsedArgs := ParseArgs(rawArgs)
+	parsedArgs.projection = query.Projection
+	if query.Pretty != nil && !cmd.Flags().Changed("pretty") {
+		viper.Set("pretty", *query.Pretty)
+	}
+	if query.SortBy != nil && !cmd.Flags().Changed("sort-by") {
+		viper.Set("sort-by", query.SortBy)
+	}
+	if query.Jq != "" && !cmd.Flags().Changed("jq") {
+		viper.Set("jq", query.Jq)
+	}
+	if query.Template != "" && !cmd.Flags().Changed("template") && !cmd.Flags().Changed("template-file") {
+		viper.Set("template", query.Template)
+	}
+	queryInput, err := buildQueryInput(cmd.Context(), parsedArgs, append(filterArgs, viper.GetStringSlice("filter")...))
+	if err != nil {
+		return err
+	}
+	return printItems(internal.IterateQuery(cmd.Context(), client, queryInput))
+}
+
+func listSavedQueries(queries map[string]savedQuery) {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		query := queries[name]
+		fmt.Printf("%s\t%s", name, query.Table)
+		if query.Index != "" {
+			fmt.Printf(":%s", query.Index)
+		}
+		if params := queryParameters(query); len(params) > 0 {
+			fmt.Printf("\tparams: %s", strings.Join(params, ", "))
+		}
+		if query.Description != "" {
+			fmt.Printf("\t%s", query.Description)
+		}
+		fmt.Println()
+	}
+}
+
+// queryParameters lists the distinct placeholder names of a saved query
+func queryParameters(query savedQuery) []string {
+	var params []string
+	for _, text := range slices.Concat([]string{query.Table, query.Index}, query.Key, query.Filters) {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(params, match[1]) {
+				params = append(params, match[1])
+			}
+		}
+	}
+	return params
+}
+
+// expandPlaceholders replaces every {{name}} with its param, failing on any missing param
+func expandPlaceholders(texts []string, params map[string]string) ([]string, error) {
+	var missing []string
+	expanded := make([]string, 0, len(texts))
+	for _, text := range texts {
+		expanded = append(expanded, placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			name := placeholder.FindStringSubmatch(match)[1]
+			value, found := params[name]
+			if !found && !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+			return value
+		}))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing params: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringArray("param", []string{}, "placeholder value as name=value")
+	runCmd.Flags().Bool("list", false, "list the saved queries")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	params := map[string]string{"customer": "123", "since": "2024"}
+	var tests = []struct {
+		name            string
+		texts, expanded []string
+		valid           bool
+	}{
+		{"none", []string{"orders", "abc"}, []string{"orders", "abc"}, true},
+		{"key", []string{"{{customer}}", ">={{ since }}"}, []string{"123", ">=2024"}, true},
+		{"repeated", []string{"id={{customer}}-{{customer}}"}, []string{"id=123-123"}, true},
+		{"missing", []string{"{{customer}}", "status={{status}}"}, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expanded, err := expandPlaceholders(test.texts, params)
+			if (err == nil) != test.valid || !slices.Equal(expanded, test.expanded) {
+				t.Errorf("got %q, %v want %q", expanded, err, test.expanded)
+			}
+		})
+	}
+}
+
+func TestRunSavedQuery(t *testing.T) {
+	fake := newOrdersFake(t)
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	config := `queries:
+  by-status:
+    table: "{{table}}"
+    index: byStatus
+    key: ["{{status}}"]
+    sort-by: [total:desc]
+    jq: .order
+  misspelt:
+    table: orders
+    key: [a]
+    output: json
+`
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output, err := runCommand(t, fake, "run", "by-status", "--param", "table=orders", "--param", "status=open", "--config", configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "3\n1\n" {
+		t.Errorf("got %q want the orders of open orders by descending total", output)
+	}
+	// flags take precedence over the settings of the saved query
+	output, err = runCommand(t, fake, "run", "by-status", "--param", "table=orders", "--param", "status=open", "--jq", ".total", "--config", configFile)
+	if err != nil || output != "30\n5\n" {
+		t.Errorf("got %q, %v want the totals of open orders", output, err)
+	}
+	if _, err := runCommand(t, fake, "run", "misspelt", "--config", configFile); err == nil || !strings.Contains(err.Error(), "output") {
+		t.Errorf("got %v for an unknown setting of a saved query", err)
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	query := savedQuery{Table: "{{table}}", Index: "{{index}}", Key: []string{"{{customer}}"}, Filters: []string{"status={{status}}", "id={{customer}}"}}
+	if params, want := queryParameters(query), []string{"table", "index", "customer", "status"}; !slices.Equal(params, want) {
+		t.Errorf("got %q want %q", params, want)
+	}
+}
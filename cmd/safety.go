@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// Access classifies what an operation does to a table
+type Access int
+
+const (
+	ReadAccess        Access = iota
+	WriteAccess              // creates or changes items or tables
+	DestructiveAccess        // deletes items or tables
+)
+
+func (access Access) String() string {
+	switch access {
+	case WriteAccess:
+		return "write"
+	case DestructiveAccess:
+		return "destructive"
+	}
+	return "read"
+}
+
+var statementTable = regexp.MustCompile(`(?is)^\s*(?:select\b.*?\bfrom|insert\s+into|update|delete\s+from)\s+("[^"]+"|[A-Za-z0-9_-]+)`)
+
+// checkWriteAllowed refuses writes when the environment or one of the tables is read-only
+func checkWriteAllowed(tableNames ...string) error {
+	if viper.GetBool("read-only") {
+		return fmt.Errorf("refusing to write, the environment is read-only")
+	}
+	for _, tableName := range tableNames {
+		if tableName == "" && len(viper.GetStringSlice("read-only-tables")) > 0 {
+			return fmt.Errorf("refusing to write, the table isn't known to check it against read-only-tables")
+		}
+		for _, pattern := range viper.GetStringSlice("read-only-tables") {
+			if matched, _ := path.Match(pattern, tableName); matched {
+				return fmt.Errorf("refusing to write, table %s matches read-only pattern %s", tableName, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// guardWrite checks a write is allowed and, when it affects more than the
+// confirm-threshold, asks for confirmation unless --yes was given. The count is
+// of the unit written, e.g. items or statements.
+// Destructive operations also need a terminal on stdin unless --force was given.
+func guardWrite(access Access, preview string, count int, unit string, tableNames ...string) error {
+	if access == ReadAccess {
+		return nil
+	}
+	if err := checkWriteAllowed(tableNames...); err != nil {
+		return err
+	}
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if access == DestructiveAccess && !interactive && !viper.GetBool("force") {
+		return fmt.Errorf("refusing to %s without a terminal on stdin, use --force", preview)
+	}
+	if count <= viper.GetInt("confirm-threshold") || viper.GetBool("yes") {
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("%s needs confirmation, use --yes", preview)
+	}
+	return confirm(preview, fmt.Sprintf("%s (%d %s in %s)", preview, count, unit, strings.Join(tableNames, ", ")))
+}
+
+// guardTableWrite checks a change to whole tables is allowed, destructive
+// changes always ask for confirmation unless --yes was given and, like
+// guardWrite, need a terminal on stdin unless --force was given
+func guardTableWrite(access Access, preview string, tableNames ...string) error {
+	if err := checkWriteAllowed(tableNames...); err != nil {
+		return err
+	}
+	if access != DestructiveAccess {
+		return nil
+	}
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if !interactive && !viper.GetBool("force") {
+		return fmt.Errorf("refusing to %s without a terminal on stdin, use --force", preview)
+	}
+	if viper.GetBool("yes") {
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("%s needs confirmation, use --yes", preview)
+	}
+	return confirm(preview, preview)
+}
+
+func confirm(preview, question string) error {
+	fmt.Fprintf(os.Stderr, "%s. Continue? [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("%s aborted", preview)
+	}
+	return nil
+}
+
+// classifyStatement returns the access and table of a PartiQL statement.
+// Statements which aren't recognized, or whose table isn't found, are
+// destructive so they get the strictest checks
+func classifyStatement(statement string) (Access, string) {
+	statement = stripLeadingComments(statement)
+	tableName := ""
+	if match := statementTable.FindStringSubmatch(statement); match != nil {
+		tableName = strings.Trim(match[1], `"`)
+	}
+	keyword := ""
+	if fields := strings.Fields(strings.ToUpper(statement)); len(fields) > 0 {
+		keyword = fields[0]
+	}
+	switch {
+	case keyword == "SELECT":
+		return ReadAccess, tableName
+	case (keyword == "INSERT" || keyword == "UPDATE") && tableName != "":
+		return WriteAccess, tableName
+	}
+	return DestructiveAccess, tableName
+}
+
+// stripLeadingComments removes the whitespace, -- line comments and /* block
+// comments */ before the first keyword of a statement
+func stripLeadingComments(statement string) string {
+	for {
+		statement = strings.TrimLeftFunc(statement, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			_, rest, found := strings.Cut(statement, "\n")
+			if !found {
+				return ""
+			}
+			statement = rest
+		case strings.HasPrefix(statement, "/*"):
+			_, rest, found := strings.Cut(statement[2:], "*/")
+			if !found {
+				return ""
+			}
+			statement = rest
+		default:
+			return statement
+		}
+	}
+}
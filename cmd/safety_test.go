@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	var tests = []struct {
+		name, statement, tableName string
+		access                     Access
+	}{
+		{"select", "SELECT * FROM orders WHERE pk='X'", "orders", ReadAccess},
+		{"selectIndex", "select a, b\nfrom \"orders\".\"byCustomer\" where c=?", "orders", ReadAccess},
+		{"insert", "INSERT INTO orders VALUE {'pk': 'X'}", "orders", WriteAccess},
+		{"update", "UPDATE \"acme-orders\" SET status='done' WHERE pk='X'", "acme-orders", WriteAccess},
+		{"delete", "DELETE FROM orders WHERE pk='X'", "orders", DestructiveAccess},
+		{"lineComment", "-- remove x\nDELETE FROM orders WHERE pk='X'", "orders", DestructiveAccess},
+		{"blockComment", "/* fix\n status */ update orders SET status='done' WHERE pk='X'", "orders", WriteAccess},
+		{"unknownTable", "UPDATE /* x */ orders SET status='done' WHERE pk='X'", "", DestructiveAccess},
+		{"unknown", "EXISTS(SELECT * FROM orders)", "", DestructiveAccess},
+		{"onlyComment", "-- nothing", "", DestructiveAccess},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			access, tableName := classifyStatement(test.statement)
+			if access != test.access || tableName != test.tableName {
+				t.Errorf("got %s, %s want %s, %s", access, tableName, test.access, test.tableName)
+			}
+		})
+	}
+}
+
+func TestCheckWriteAllowed(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("read-only-tables", []string{"*-prod-*"})
+	if err := checkWriteAllowed("acme-dev-orders"); err != nil {
+		t.Errorf("got %v want write allowed", err)
+	}
+	if err := checkWriteAllowed("acme-dev-orders", "acme-prod-orders"); err == nil {
+		t.Errorf("got write allowed for a read-only table")
+	}
+	if err := checkWriteAllowed(""); err == nil {
+		t.Errorf("got write allowed for an unknown table")
+	}
+	viper.Set("read-only", true)
+	if err := checkWriteAllowed("acme-dev-orders"); err == nil {
+		t.Errorf("got write allowed in a read-only environment")
+	}
+}
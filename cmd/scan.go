@@ -4,10 +4,8 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
+	"iter"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -29,25 +27,23 @@ to quickly create a Cobra application.`,
 }
 
 func runScan(cmd *cobra.Command, args []string) error {
-	tableName := args[0]
-	paginator := internal.IterateScan(client, dynamodb.ScanInput{
-		TableName: &tableName,
-	})
+	paginator, err := scanItems(cmd, args)
+	if err != nil {
+		return err
+	}
 
-	unmarshaller := internal.UnmarshalItems(paginator)
+	return printItems(paginator)
+}
 
-	for item, err := range unmarshaller {
-		if err != nil {
-			return err
-		}
-		itemJson, err := json.Marshal(item)
-		if err != nil {
-			return fmt.Errorf("Failed to Marshal item as json [%w]", err)
-		}
-		internal.PrintJson(itemJson, viper.GetBool("pretty"), viper.GetBool("color"))
+// scanItems scans the table of the arguments of scan, keeping the items
+// matching --filter
+func scanItems(cmd *cobra.Command, args []string) (iter.Seq2[internal.Item, error], error) {
+	tableName := resolveTableName(args[0])
+	scanInput, err := buildScanInput(tableName, viper.GetStringSlice("filter"), nil)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return internal.IterateScan(cmd.Context(), client, scanInput), nil
 }
 
 func init() {
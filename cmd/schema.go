@@ -0,0 +1,163 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "infer the schema of the items of a table",
+}
+
+var schemaInferCmd = &cobra.Command{
+	Use:   "infer <table[:index]>",
+	Short: "infer the attributes of the items from a sample",
+	Long: `Scan the first items of a table or index, or all of them with --all, and
+report every attribute path with its types, how often it is present and
+example values. Nested attributes are reported as map.attribute and
+list[].attribute, their presence is relative to the maps or list elements.
+
+For single table designs items are grouped into entities by the prefix of the
+sort key up to the separator, e.g. ORDER in ORDER#2024-01-01. Tables without a
+sort key are grouped by the partition key.
+
+  ddb schema infer orders
+  ddb schema infer app --all --separator '#'
+  ddb schema infer app:byStatus --format json-schema > app.schema.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSchemaInfer,
+}
+
+func runSchemaInfer(cmd *cobra.Command, args []string) error {
+	tableArg, indexName, _ := strings.Cut(args[0], ":")
+	tableName := resolveTableName(tableArg)
+	flags := cmd.Flags()
+	format, _ := flags.GetString("format")
+	if format != "report" && format != "json-schema" {
+		return fmt.Errorf("invalid --format %q, expected report or json-schema", format)
+	}
+	limit, _ := flags.GetInt("limit")
+	all, _ := flags.GetBool("all")
+	if limit < 1 && !all {
+		return fmt.Errorf("--limit must be positive, use --all to scan every item")
+	}
+	separator, _ := flags.GetString("separator")
+
+	schema, err := inferSchema(cmd, tableName, indexName, limit, all, separator)
+	if err != nil {
+		return err
+	}
+	if format == "json-schema" {
+		return printJson(schema.JsonSchema(args[0]))
+	}
+	return writeSchemaReport(os.Stdout, schema)
+}
+
+// inferSchema scans the table or index and groups the items by the --group-by
+// attribute, by default the sort key or else the partition key
+func inferSchema(cmd *cobra.Command, tableName, indexName string, limit int, all bool, separator string) (*internal.Schema, error) {
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return nil, err
+	}
+	path, found := findAccessPath(accessPaths(description), indexName)
+	if !found {
+		return nil, fmt.Errorf("table: %s doesn't have an index: %s", tableName, indexName)
+	}
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	if !cmd.Flags().Changed("group-by") {
+		groupBy = path.keys[len(path.keys)-1].Name
+	}
+
+	scanInput, err := buildScanInput(tableName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if indexName != "" {
+		scanInput.IndexName = &indexName
+	}
+	if !all {
+		scanInput.Limit = aws.Int32(int32(min(limit, 1000)))
+	}
+	items := internal.IterateScan(cmd.Context(), client, scanInput)
+	if !all {
+		items = firstItems(items, limit)
+	}
+	schema, err := internal.InferSchema(items, groupBy, separator)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug(fmt.Sprintf("found %d entities in %s grouping by %q", len(schema.Entities), tableName, groupBy))
+	return schema, nil
+}
+
+// firstItems stops after the given number of items
+func firstItems(items iter.Seq2[internal.Item, error], limit int) iter.Seq2[internal.Item, error] {
+	return func(yield func(internal.Item, error) bool) {
+		count := 0
+		for item, err := range items {
+			count++
+			if !yield(item, err) || count == limit {
+				return
+			}
+		}
+	}
+}
+
+// writeSchemaReport writes a table of the attributes of each entity
+func writeSchemaReport(output io.Writer, schema *internal.Schema) error {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	for index, entity := range schema.Entities {
+		if index > 0 {
+			fmt.Fprintln(writer)
+		}
+		name := entity.Prefix
+		if name == "" {
+			name = "(no prefix)"
+		}
+		if schema.GroupBy == "" || len(schema.Entities) == 1 && entity.Prefix == "" {
+			name = "items"
+		}
+		fmt.Fprintf(writer, "%s: %d items\n", name, entity.Items)
+		fmt.Fprintln(writer, "PATH\tTYPES\tPRESENCE\tEXAMPLES")
+		for _, path := range entity.Paths() {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", path.Path, strings.Join(path.TypeNames(), "|"),
+				presence(path.Count, path.Parent), strings.Join(path.Examples, ", "))
+		}
+	}
+	return writer.Flush()
+}
+
+// presence is the share of the parents holding an attribute, list elements
+// are all present
+func presence(count, parent int) string {
+	if parent == 0 || count >= parent {
+		return "100%"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(count)/float64(parent))
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaInferCmd)
+
+	schemaInferCmd.Flags().Int("limit", 1000, "number of items to sample")
+	schemaInferCmd.Flags().Bool("all", false, "scan every item instead of a sample")
+	schemaInferCmd.Flags().String("group-by", "", "attribute whose prefix names the entity of an item, empty not to group (default the sort key, or the partition key)")
+	schemaInferCmd.Flags().String("separator", "#", "separator ending the entity prefix of the group by attribute")
+	schemaInferCmd.Flags().String("format", "report", "report or json-schema")
+}
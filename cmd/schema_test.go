@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaInfer(t *testing.T) {
+	fake := newOrdersFake(t)
+	output, err := runCommand(t, fake, "schema", "infer", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `items: 3 items
+PATH      TYPES  PRESENCE  EXAMPLES
+customer  S      100%      a, b
+order     N      100%      1, 2, 3
+status    S      100%      open, shipped
+total     N      100%      5, 20, 30
+`
+	if output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+
+	output, err = runCommand(t, fake, "schema", "infer", "orders:byStatus", "--limit", "1", "--format", "json-schema", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, `"required":["customer","order","status","total"]`) || !strings.Contains(output, `"title":"orders:byStatus"`) {
+		t.Errorf("got %s, want a json schema of one item", output)
+	}
+	if _, err := runCommand(t, fake, "schema", "infer", "orders:missing"); err == nil {
+		t.Errorf("got no error for a missing index")
+	}
+}
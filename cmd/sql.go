@@ -0,0 +1,216 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// sqlCmd represents the sql command
+var sqlCmd = &cobra.Command{
+	Use:   "sql [statement]",
+	Short: "run PartiQL statements",
+	Long: `Run a PartiQL statement against dynamodb, or a batch of statements from a file.
+
+Statements in a file are separated by semicolons and run as one batch of at
+most 25, the limit of dynamodb. Values for ? placeholders are
+bound in order from --param flags, using the same literal rules as filters:
+numbers are bound as N, anything else (optionally quoted) as S.
+
+Example:
+  ddb sql "SELECT * FROM orders WHERE pk=? AND begins_with(sk, ?)" -P X -P 2024`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSql,
+}
+
+func runSql(cmd *cobra.Command, args []string) error {
+	statementFile, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return err
+	}
+	parameterArgs, err := cmd.Flags().GetStringArray("param")
+	if err != nil {
+		return err
+	}
+
+	var statements []string
+	switch {
+	case statementFile != "" && len(args) == 0:
+		contents, err := os.ReadFile(statementFile)
+		if err != nil {
+			return fmt.Errorf("failed to read statement file: %w", err)
+		}
+		statements = SplitStatements(string(contents))
+		if len(statements) == 0 {
+			return fmt.Errorf("no statements found in %s", statementFile)
+		}
+	case statementFile == "" && len(args) == 1:
+		statements = args
+	default:
+		return fmt.Errorf("sql requires either a statement argument or --file")
+	}
+
+	if len(statements) > internal.BatchStatementLimit {
+		return fmt.Errorf("a batch holds at most %d statements, %s has %d", internal.BatchStatementLimit, statementFile, len(statements))
+	}
+	requests, err := bindParameters(statements, parameterArgs)
+	if err != nil {
+		return err
+	}
+
+	access := ReadAccess
+	writes := 0
+	var tableNames []string
+	for _, statement := range statements {
+		statementAccess, tableName := classifyStatement(statement)
+		if statementAccess == ReadAccess {
+			continue
+		}
+		writes++
+		access = max(access, statementAccess)
+		if !slices.Contains(tableNames, tableName) {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	if err := guardWrite(access, fmt.Sprintf("run %d %s statements", writes, access), writes, "statements", tableNames...); err != nil {
+		return err
+	}
+
+	if len(requests) == 1 {
+		logger.Debug("running statement")
+		return printItems(internal.IterateStatement(cmd.Context(), client, dynamodb.ExecuteStatementInput{
+			Statement:  requests[0].Statement,
+			Parameters: requests[0].Parameters,
+		}))
+	}
+
+	logger.Debug(fmt.Sprintf("running batch of %d statements", len(requests)))
+	responses, err := internal.BatchExecuteStatement(cmd.Context(), client, requests)
+	if err != nil {
+		return err
+	}
+	var items []internal.Item
+	failed := 0
+	for index, response := range responses {
+		if response.Error != nil {
+			failed++
+			logger.Error(fmt.Sprintf("statement %d failed: %s %s", index+1, response.Error.Code, aws.ToString(response.Error.Message)))
+			continue
+		}
+		if len(response.Item) > 0 {
+			items = append(items, response.Item)
+		}
+	}
+	err = printItems(func(yield func(internal.Item, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d statements failed", failed, len(responses))
+	}
+	return nil
+}
+
+// bindParameters assigns parameter values to the ? placeholders of each statement in order
+func bindParameters(statements []string, parameterArgs []string) ([]types.BatchStatementRequest, error) {
+	total := 0
+	for _, statement := range statements {
+		total += CountParameters(statement)
+	}
+	if total != len(parameterArgs) {
+		return nil, fmt.Errorf("statements have %d parameters, %d were provided", total, len(parameterArgs))
+	}
+
+	var requests []types.BatchStatementRequest
+	for _, statement := range statements {
+		count := CountParameters(statement)
+		var parameters []types.AttributeValue
+		for _, parameterArg := range parameterArgs[:count] {
+			value, valueType := ParseArgValue(parameterArg)
+			parameter, err := internal.MarshalArgument(value, valueType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal parameter %s to inferred type %s [%w]", parameterArg, valueType, err)
+			}
+			parameters = append(parameters, parameter)
+		}
+		parameterArgs = parameterArgs[count:]
+		requests = append(requests, types.BatchStatementRequest{
+			Statement:  aws.String(statement),
+			Parameters: parameters,
+		})
+	}
+	return requests, nil
+}
+
+// forEachUnquoted calls fn for every rune of a statement that isn't inside a
+// quoted string ('...') or quoted identifier ("...")
+func forEachUnquoted(statement string, fn func(int, rune)) {
+	var quote rune
+	for index, char := range statement {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		default:
+			fn(index, char)
+		}
+	}
+}
+
+// SplitStatements splits PartiQL text on semicolons outside of quotes
+func SplitStatements(text string) []string {
+	var statements []string
+	start := 0
+	var ends []int
+	forEachUnquoted(text, func(index int, char rune) {
+		if char == ';' {
+			ends = append(ends, index)
+		}
+	})
+	for _, end := range append(ends, len(text)) {
+		if statement := strings.TrimSpace(text[start:end]); statement != "" {
+			statements = append(statements, statement)
+		}
+		start = end + 1
+	}
+	return statements
+}
+
+// CountParameters counts the ? placeholders of a statement outside of quotes
+func CountParameters(statement string) int {
+	count := 0
+	forEachUnquoted(statement, func(_ int, char rune) {
+		if char == '?' {
+			count++
+		}
+	})
+	return count
+}
+
+func init() {
+	rootCmd.AddCommand(sqlCmd)
+
+	sqlCmd.Flags().String("file", "", "file of semicolon separated statements to run as a batch")
+	sqlCmd.Flags().StringArrayP("param", "P", []string{}, "value for the next ? placeholder")
+}
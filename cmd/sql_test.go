@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestSplitStatements(t *testing.T) {
+	var tests = []struct {
+		name, text string
+		statements []string
+	}{
+		{"single", "SELECT * FROM t", []string{"SELECT * FROM t"}},
+		{"trailingSemicolon", "SELECT * FROM t;\n", []string{"SELECT * FROM t"}},
+		{"multiple", "DELETE FROM t WHERE pk=1;\nDELETE FROM t WHERE pk=2;", []string{"DELETE FROM t WHERE pk=1", "DELETE FROM t WHERE pk=2"}},
+		{"quotedSemicolon", "UPDATE t SET a='x;y' WHERE pk=1; SELECT * FROM \"a;b\"", []string{"UPDATE t SET a='x;y' WHERE pk=1", "SELECT * FROM \"a;b\""}},
+		{"empty", " ; ", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements := SplitStatements(test.text)
+			if !slices.Equal(statements, test.statements) {
+				t.Errorf("got %q want %q", statements, test.statements)
+			}
+		})
+	}
+}
+
+func TestCountParameters(t *testing.T) {
+	var tests = []struct {
+		name, statement string
+		count           int
+	}{
+		{"none", "SELECT * FROM t", 0},
+		{"two", "SELECT * FROM t WHERE pk=? AND begins_with(sk, ?)", 2},
+		{"quoted", "SELECT * FROM t WHERE pk='?' AND sk=?", 1},
+		{"escapedQuote", "SELECT * FROM t WHERE pk='it''s?' AND sk=?", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if count := CountParameters(test.statement); count != test.count {
+				t.Errorf("got %d want %d", count, test.count)
+			}
+		})
+	}
+}
+
+// statementFake answers the PartiQL statements FakeDynamodb doesn't support,
+// a statement returns an item per parameter and fails when it reads from missing
+type statementFake struct {
+	*internal.FakeDynamodb
+	batches int
+}
+
+func (f *statementFake) item(statement string, parameters []types.AttributeValue) (internal.Item, *types.BatchStatementError) {
+	if strings.Contains(statement, "missing") {
+		return nil, &types.BatchStatementError{Code: types.BatchStatementErrorCodeEnumResourceNotFound, Message: aws.String("table missing not found")}
+	}
+	return internal.Item{"statement": &types.AttributeValueMemberN{Value: "1"}, "parameter": parameters[0]}, nil
+}
+
+// ExecuteStatement returns the item on two pages, the second one empty
+func (f *statementFake) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	if params.NextToken != nil {
+		return &dynamodb.ExecuteStatementOutput{}, nil
+	}
+	item, err := f.item(*params.Statement, params.Parameters)
+	if err != nil {
+		return nil, &types.ResourceNotFoundException{Message: err.Message}
+	}
+	return &dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{item}, NextToken: aws.String("next")}, nil
+}
+
+func (f *statementFake) BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	f.batches++
+	output := &dynamodb.BatchExecuteStatementOutput{}
+	for _, statement := range params.Statements {
+		item, err := f.item(*statement.Statement, statement.Parameters)
+		output.Responses = append(output.Responses, types.BatchStatementResponse{Item: item, Error: err})
+	}
+	return output, nil
+}
+
+func TestSql(t *testing.T) {
+	fake := &statementFake{FakeDynamodb: newOrdersFake(t)}
+	output, err := runCommand(t, fake, "sql", "SELECT * FROM orders WHERE customer=?", "-P", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output = strings.TrimSpace(output); output != `{"parameter":"a","statement":1}` {
+		t.Errorf("got %s for a statement", output)
+	}
+
+	directory := t.TempDir()
+	statementFile := filepath.Join(directory, "statements.sql")
+	if err := os.WriteFile(statementFile, []byte("SELECT * FROM orders WHERE customer=?;\nSELECT * FROM missing WHERE customer=?;"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output, err = runCommand(t, fake, "sql", "--file", statementFile, "-P", "a", "-P", "b")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 statements failed") {
+		t.Errorf("got %v want the failed statement", err)
+	}
+	if output = strings.TrimSpace(output); output != `{"parameter":"a","statement":1}` {
+		t.Errorf("got %s for a batch", output)
+	}
+
+	if err := os.WriteFile(statementFile, []byte(strings.Repeat("SELECT * FROM orders;\n", internal.BatchStatementLimit+1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "sql", "--file", statementFile); err == nil {
+		t.Errorf("got no error for more than %d statements", internal.BatchStatementLimit)
+	}
+	if fake.batches != 1 {
+		t.Errorf("got %d batches want 1, a batch over the limit isn't sent", fake.batches)
+	}
+}
@@ -0,0 +1,501 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// tableSpec is a CreateTable request in the format of the api, plus the ttl attribute
+type tableSpec struct {
+	dynamodb.CreateTableInput
+	TimeToLiveAttribute string
+}
+
+// tableCmd represents the table command
+var tableCmd = &cobra.Command{
+	Use:   "table",
+	Short: "create, describe, update and delete tables",
+}
+
+var tableCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "create a table from a spec or like another table",
+	Long: `Create a table from a YAML or JSON spec in the format of the CreateTable api,
+plus an optional TimeToLiveAttribute, or with the keys, indexes, billing mode,
+stream, ttl and deletion protection of an existing table with --like.
+The name argument overrides the TableName of the spec.
+
+  TableName: orders
+  AttributeDefinitions:
+    - {AttributeName: customer, AttributeType: S}
+    - {AttributeName: order, AttributeType: N}
+    - {AttributeName: status, AttributeType: S}
+  KeySchema:
+    - {AttributeName: customer, KeyType: HASH}
+    - {AttributeName: order, KeyType: RANGE}
+  GlobalSecondaryIndexes:
+    - IndexName: byStatus
+      KeySchema: [{AttributeName: status, KeyType: HASH}]
+      Projection: {ProjectionType: ALL}
+  BillingMode: PAY_PER_REQUEST
+  StreamSpecification: {StreamEnabled: true, StreamViewType: NEW_AND_OLD_IMAGES}
+  DeletionProtectionEnabled: true
+  TimeToLiveAttribute: expiresAt
+
+  ddb table create --file orders.yaml
+  ddb table create orders-copy --like orders`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTableCreate,
+}
+
+var tableDescribeCmd = &cobra.Command{
+	Use:   "describe <name>",
+	Short: "print the description of a table",
+	Long: `Print the description of a table with its point in time recovery status and
+restorable period.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTableDescribe,
+}
+
+var tableUpdateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "add or remove global indexes and change the billing mode",
+	Long: `Change the billing mode or throughput of a table, add and remove global
+secondary indexes, and turn deletion protection or point in time recovery on
+or off. Indexes are added and removed one at a time, waiting for each change
+to finish.
+
+Switching to the provisioned billing mode gives the indexes the throughput of
+the table, afterwards --read and --write only change the table and --index with
+--index-read and --index-write change an index.
+
+Indexes to add are given as name=partition[:type][,sort[:type]], types are
+S (default), N or B.
+
+  ddb table update orders --billing-mode provisioned --read 5 --write 5
+  ddb table update orders --index byStatus --index-read 20
+  ddb table update orders --add-index byStatus=status,created:N --projection keys-only
+  ddb table update orders --remove-index byStatus
+  ddb table update orders --point-in-time-recovery`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTableUpdate,
+}
+
+var tableDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "delete a table",
+	Long: `Delete a table and all its items, after confirmation unless --yes is given.
+Tables with deletion protection must have it turned off first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTableDelete,
+}
+
+func runTableCreate(cmd *cobra.Command, args []string) error {
+	specFile, _ := cmd.Flags().GetString("file")
+	likeTable, _ := cmd.Flags().GetString("like")
+	if (specFile == "") == (likeTable == "") {
+		return fmt.Errorf("give either --file or --like")
+	}
+
+	var spec tableSpec
+	if specFile != "" {
+		var err error
+		if spec, err = readTableSpec(specFile); err != nil {
+			return err
+		}
+	} else {
+		if len(args) == 0 {
+			return fmt.Errorf("the name of the new table is required with --like")
+		}
+		likeTable = resolveTableName(likeTable)
+		description, err := internal.DescribeTable(cmd.Context(), client, likeTable)
+		if err != nil {
+			return err
+		}
+		spec.CreateTableInput = *internal.CreateTableInputLike(description, "")
+		attributeName, status, err := internal.TimeToLiveAttribute(cmd.Context(), client, likeTable)
+		if err != nil {
+			return err
+		}
+		if status == types.TimeToLiveStatusEnabled || status == types.TimeToLiveStatusEnabling {
+			spec.TimeToLiveAttribute = attributeName
+		}
+	}
+	if len(args) == 1 {
+		spec.TableName = aws.String(args[0])
+	}
+	if aws.ToString(spec.TableName) == "" {
+		return fmt.Errorf("the spec has no TableName, give the name as an argument")
+	}
+	tableName := resolveTableName(*spec.TableName)
+	spec.TableName = &tableName
+
+	if err := guardTableWrite(WriteAccess, "create table "+tableName, tableName); err != nil {
+		return err
+	}
+	logger.Debug(fmt.Sprintf("creating table %s", tableName))
+	if err := internal.CreateTable(cmd.Context(), client, &spec.CreateTableInput); err != nil {
+		return err
+	}
+	wait, _ := cmd.Flags().GetBool("wait")
+	if !wait && spec.TimeToLiveAttribute == "" {
+		logger.Info(fmt.Sprintf("creating table %s", tableName))
+		return nil
+	}
+	// ttl can only be enabled once the table is active
+	if _, err := internal.WaitForActive(cmd.Context(), client, tableName); err != nil {
+		return err
+	}
+	if spec.TimeToLiveAttribute != "" {
+		if err := internal.UpdateTimeToLive(cmd.Context(), client, tableName, spec.TimeToLiveAttribute, true); err != nil {
+			return err
+		}
+	}
+	logger.Info(fmt.Sprintf("table %s is active", tableName))
+	return nil
+}
+
+// readTableSpec parses a YAML or JSON table spec, rejecting unknown fields
+func readTableSpec(specFile string) (tableSpec, error) {
+	contents, err := os.ReadFile(specFile)
+	if err != nil {
+		return tableSpec{}, fmt.Errorf("failed to read table spec: %w", err)
+	}
+	// yaml is a superset of json, convert it to json to decode into the api types
+	var document any
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return tableSpec{}, fmt.Errorf("failed to parse table spec [%w]", err)
+	}
+	documentJson, err := json.Marshal(document)
+	if err != nil {
+		return tableSpec{}, fmt.Errorf("failed to parse table spec [%w]", err)
+	}
+	var spec tableSpec
+	decoder := json.NewDecoder(bytes.NewReader(documentJson))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return tableSpec{}, fmt.Errorf("invalid table spec [%w]", err)
+	}
+	return spec, nil
+}
+
+// tableDetails is a table description with its point in time recovery
+type tableDetails struct {
+	*types.TableDescription
+	PointInTimeRecovery *types.PointInTimeRecoveryDescription
+}
+
+func runTableDescribe(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	pointInTimeRecovery, err := internal.PointInTimeRecovery(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	return printDescription(tableDetails{TableDescription: description, PointInTimeRecovery: pointInTimeRecovery})
+}
+
+func runTableUpdate(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	flags := cmd.Flags()
+	addIndexes, _ := flags.GetStringArray("add-index")
+	removeIndexes, _ := flags.GetStringArray("remove-index")
+	projectionArg, _ := flags.GetString("projection")
+	readUnits, _ := flags.GetInt64("read")
+	writeUnits, _ := flags.GetInt64("write")
+	wait, _ := flags.GetBool("wait")
+	if !wait && len(addIndexes)+len(removeIndexes) > 1 {
+		return fmt.Errorf("changing more than one index needs --wait, dynamodb changes one index at a time")
+	}
+
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	billingMode := types.BillingModeProvisioned
+	if description.BillingModeSummary != nil && description.BillingModeSummary.BillingMode != "" {
+		billingMode = description.BillingModeSummary.BillingMode
+	}
+
+	tableUpdate := &dynamodb.UpdateTableInput{TableName: &tableName}
+	changed := false
+	if flags.Changed("billing-mode") {
+		billingModeArg, _ := flags.GetString("billing-mode")
+		if billingMode, err = parseBillingMode(billingModeArg); err != nil {
+			return err
+		}
+		tableUpdate.BillingMode = billingMode
+		changed = true
+	}
+	var throughput *types.ProvisionedThroughput
+	if flags.Changed("read") || flags.Changed("write") {
+		if billingMode != types.BillingModeProvisioned {
+			return fmt.Errorf("--read and --write need the provisioned billing mode")
+		}
+		// the units not given keep their current value, which is 0 on demand
+		if current := description.ProvisionedThroughput; current != nil {
+			if !flags.Changed("read") {
+				readUnits = aws.ToInt64(current.ReadCapacityUnits)
+			}
+			if !flags.Changed("write") {
+				writeUnits = aws.ToInt64(current.WriteCapacityUnits)
+			}
+		}
+		if readUnits <= 0 || writeUnits <= 0 {
+			return fmt.Errorf("the table has no provisioned throughput yet, give both --read and --write")
+		}
+		throughput = &types.ProvisionedThroughput{ReadCapacityUnits: &readUnits, WriteCapacityUnits: &writeUnits}
+		tableUpdate.ProvisionedThroughput = throughput
+		changed = true
+	}
+	if tableUpdate.BillingMode == types.BillingModeProvisioned && throughput == nil {
+		return fmt.Errorf("switching to the provisioned billing mode needs --read and --write")
+	}
+	indexName, _ := flags.GetString("index")
+	indexThroughput, err := indexThroughputUpdate(flags, description, indexName, billingMode)
+	if err != nil {
+		return err
+	}
+	for _, index := range description.GlobalSecondaryIndexes {
+		var indexUpdate *types.ProvisionedThroughput
+		switch {
+		case indexThroughput != nil && aws.ToString(index.IndexName) == indexName:
+			indexUpdate = indexThroughput
+		case tableUpdate.BillingMode == types.BillingModeProvisioned:
+			// the indexes need a throughput too, existing ones are left alone otherwise
+			indexUpdate = throughput
+		default:
+			continue
+		}
+		tableUpdate.GlobalSecondaryIndexUpdates = append(tableUpdate.GlobalSecondaryIndexUpdates, types.GlobalSecondaryIndexUpdate{
+			Update: &types.UpdateGlobalSecondaryIndexAction{IndexName: index.IndexName, ProvisionedThroughput: indexUpdate},
+		})
+		changed = true
+	}
+	if flags.Changed("deletion-protection") {
+		deletionProtection, _ := flags.GetBool("deletion-protection")
+		tableUpdate.DeletionProtectionEnabled = &deletionProtection
+		changed = true
+	}
+
+	var indexUpdates []*dynamodb.UpdateTableInput
+	for _, indexName := range removeIndexes {
+		indexUpdates = append(indexUpdates, &dynamodb.UpdateTableInput{
+			TableName: &tableName,
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{
+				Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(indexName)},
+			}},
+		})
+	}
+	for _, indexArg := range addIndexes {
+		index, definitions, err := parseIndexArg(indexArg)
+		if err != nil {
+			return err
+		}
+		if index.Projection, err = parseProjection(projectionArg); err != nil {
+			return err
+		}
+		if billingMode == types.BillingModeProvisioned {
+			index.ProvisionedThroughput = throughput
+			if index.ProvisionedThroughput == nil {
+				index.ProvisionedThroughput = &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)}
+			}
+		}
+		indexUpdates = append(indexUpdates, &dynamodb.UpdateTableInput{
+			TableName:                   &tableName,
+			AttributeDefinitions:        definitions,
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Create: &index}},
+		})
+	}
+	// point in time recovery is changed with its own request
+	pointInTimeRecovery := flags.Changed("point-in-time-recovery")
+	if !changed && len(indexUpdates) == 0 && !pointInTimeRecovery {
+		return fmt.Errorf("nothing to update, see ddb table update --help")
+	}
+
+	access := WriteAccess
+	if len(removeIndexes) > 0 {
+		access = DestructiveAccess
+	}
+	preview := fmt.Sprintf("update table %s", tableName)
+	if len(removeIndexes) > 0 {
+		preview += fmt.Sprintf(" removing index %s", strings.Join(removeIndexes, ", "))
+	}
+	if err := guardTableWrite(access, preview, tableName); err != nil {
+		return err
+	}
+	if changed {
+		indexUpdates = append([]*dynamodb.UpdateTableInput{tableUpdate}, indexUpdates...)
+	}
+	for _, update := range indexUpdates {
+		if err := internal.UpdateTable(cmd.Context(), client, update); err != nil {
+			return err
+		}
+		if wait {
+			if _, err := internal.WaitForActive(cmd.Context(), client, tableName); err != nil {
+				return err
+			}
+		}
+	}
+	if pointInTimeRecovery {
+		enabled, _ := flags.GetBool("point-in-time-recovery")
+		if err := internal.UpdatePointInTimeRecovery(cmd.Context(), client, tableName, enabled); err != nil {
+			return err
+		}
+	}
+	if wait {
+		logger.Info(fmt.Sprintf("table %s is active", tableName))
+	} else {
+		logger.Info(fmt.Sprintf("updating table %s", tableName))
+	}
+	return nil
+}
+
+// indexThroughputUpdate returns the throughput of --index-read and --index-write
+// for the --index, the units not given keep their current value
+func indexThroughputUpdate(flags *pflag.FlagSet, description *types.TableDescription, indexName string, billingMode types.BillingMode) (*types.ProvisionedThroughput, error) {
+	if !flags.Changed("index-read") && !flags.Changed("index-write") {
+		if indexName != "" {
+			return nil, fmt.Errorf("--index needs --index-read or --index-write")
+		}
+		return nil, nil
+	}
+	if indexName == "" {
+		return nil, fmt.Errorf("--index-read and --index-write need --index")
+	}
+	if billingMode != types.BillingModeProvisioned {
+		return nil, fmt.Errorf("--index-read and --index-write need the provisioned billing mode")
+	}
+	index := slices.IndexFunc(description.GlobalSecondaryIndexes, func(index types.GlobalSecondaryIndexDescription) bool {
+		return aws.ToString(index.IndexName) == indexName
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("table %s has no global index %s", aws.ToString(description.TableName), indexName)
+	}
+	readUnits, _ := flags.GetInt64("index-read")
+	writeUnits, _ := flags.GetInt64("index-write")
+	if current := description.GlobalSecondaryIndexes[index].ProvisionedThroughput; current != nil {
+		if !flags.Changed("index-read") {
+			readUnits = aws.ToInt64(current.ReadCapacityUnits)
+		}
+		if !flags.Changed("index-write") {
+			writeUnits = aws.ToInt64(current.WriteCapacityUnits)
+		}
+	}
+	if readUnits <= 0 || writeUnits <= 0 {
+		return nil, fmt.Errorf("index %s has no provisioned throughput yet, give both --index-read and --index-write", indexName)
+	}
+	return &types.ProvisionedThroughput{ReadCapacityUnits: &readUnits, WriteCapacityUnits: &writeUnits}, nil
+}
+
+func parseBillingMode(arg string) (types.BillingMode, error) {
+	switch strings.ToLower(arg) {
+	case "on-demand", "pay_per_request":
+		return types.BillingModePayPerRequest, nil
+	case "provisioned":
+		return types.BillingModeProvisioned, nil
+	}
+	return "", fmt.Errorf("unknown billing mode %s, use on-demand or provisioned", arg)
+}
+
+// parseIndexArg parses name=partition[:type][,sort[:type]] to a global index with its attribute definitions
+func parseIndexArg(arg string) (types.CreateGlobalSecondaryIndexAction, []types.AttributeDefinition, error) {
+	indexName, keys, found := strings.Cut(arg, "=")
+	if !found || indexName == "" || keys == "" {
+		return types.CreateGlobalSecondaryIndexAction{}, nil, fmt.Errorf("index %s must be of the form name=partition[:type][,sort[:type]]", arg)
+	}
+	index := types.CreateGlobalSecondaryIndexAction{IndexName: aws.String(indexName)}
+	var definitions []types.AttributeDefinition
+	keyTypes := []types.KeyType{types.KeyTypeHash, types.KeyTypeRange}
+	keyArgs := strings.Split(keys, ",")
+	if len(keyArgs) > len(keyTypes) {
+		return types.CreateGlobalSecondaryIndexAction{}, nil, fmt.Errorf("index %s has more than a partition and a sort key", arg)
+	}
+	for position, keyArg := range keyArgs {
+		attributeName, attributeType, _ := strings.Cut(keyArg, ":")
+		scalarType := types.ScalarAttributeType(strings.ToUpper(attributeType))
+		if attributeType == "" {
+			scalarType = types.ScalarAttributeTypeS
+		}
+		if scalarType != types.ScalarAttributeTypeS && scalarType != types.ScalarAttributeTypeN && scalarType != types.ScalarAttributeTypeB {
+			return types.CreateGlobalSecondaryIndexAction{}, nil, fmt.Errorf("key %s of index %s must have type S, N or B", attributeName, indexName)
+		}
+		index.KeySchema = append(index.KeySchema, types.KeySchemaElement{AttributeName: aws.String(attributeName), KeyType: keyTypes[position]})
+		definitions = append(definitions, types.AttributeDefinition{AttributeName: aws.String(attributeName), AttributeType: scalarType})
+	}
+	return index, definitions, nil
+}
+
+// parseProjection parses all, keys-only or a list of attributes to include
+func parseProjection(arg string) (*types.Projection, error) {
+	switch strings.ToLower(arg) {
+	case "", "all":
+		return &types.Projection{ProjectionType: types.ProjectionTypeAll}, nil
+	case "keys-only", "keys_only":
+		return &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly}, nil
+	}
+	return &types.Projection{ProjectionType: types.ProjectionTypeInclude, NonKeyAttributes: strings.Split(arg, ",")}, nil
+}
+
+func runTableDelete(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	description, err := internal.DescribeTable(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	preview := fmt.Sprintf("delete table %s with about %d items", tableName, aws.ToInt64(description.ItemCount))
+	if err := guardTableWrite(DestructiveAccess, preview, tableName); err != nil {
+		return err
+	}
+	if err := internal.DeleteTable(cmd.Context(), client, tableName); err != nil {
+		return err
+	}
+	if wait, _ := cmd.Flags().GetBool("wait"); wait {
+		if err := internal.WaitForDeleted(cmd.Context(), client, tableName); err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("table %s is deleted", tableName))
+	} else {
+		logger.Info(fmt.Sprintf("deleting table %s", tableName))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(tableCmd)
+	tableCmd.AddCommand(tableCreateCmd, tableDescribeCmd, tableUpdateCmd, tableDeleteCmd)
+
+	tableCmd.PersistentFlags().Bool("wait", true, "wait until the table and its indexes are active, or the table is deleted")
+	tableCreateCmd.Flags().String("file", "", "YAML or JSON spec of the table")
+	tableCreateCmd.Flags().String("like", "", "existing table to copy the schema of")
+	tableUpdateCmd.Flags().String("billing-mode", "", "on-demand or provisioned")
+	tableUpdateCmd.Flags().Int64("read", 0, "provisioned read capacity units of the table, and of its indexes when switching to provisioned")
+	tableUpdateCmd.Flags().Int64("write", 0, "provisioned write capacity units of the table, and of its indexes when switching to provisioned")
+	tableUpdateCmd.Flags().String("index", "", "global index whose throughput --index-read and --index-write change")
+	tableUpdateCmd.Flags().Int64("index-read", 0, "provisioned read capacity units of the --index")
+	tableUpdateCmd.Flags().Int64("index-write", 0, "provisioned write capacity units of the --index")
+	tableUpdateCmd.Flags().StringArray("add-index", []string{}, "global index to add as name=partition[:type][,sort[:type]]")
+	tableUpdateCmd.Flags().StringArray("remove-index", []string{}, "global index to remove")
+	tableUpdateCmd.Flags().String("projection", "all", "attributes of added indexes, all, keys-only or a list of attributes")
+	tableUpdateCmd.Flags().Bool("deletion-protection", false, "turn deletion protection on or off")
+	tableUpdateCmd.Flags().Bool("point-in-time-recovery", false, "turn point in time recovery on or off")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestTableCreate(t *testing.T) {
+	fake := newOrdersFake(t)
+	specFile := filepath.Join(t.TempDir(), "events.yaml")
+	err := os.WriteFile(specFile, []byte(`
+TableName: events
+AttributeDefinitions:
+  - {AttributeName: id, AttributeType: S}
+KeySchema:
+  - {AttributeName: id, KeyType: HASH}
+BillingMode: PAY_PER_REQUEST
+TimeToLiveAttribute: expiresAt
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "table", "create", "--file", specFile); err != nil {
+		t.Fatal(err)
+	}
+	if attribute, _, err := internal.TimeToLiveAttribute(context.TODO(), fake, "events"); err != nil || attribute != "expiresAt" {
+		t.Errorf("got ttl attribute %q, %v", attribute, err)
+	}
+
+	if _, err := runCommand(t, fake, "table", "create", "orders-copy", "--like", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	description, err := internal.DescribeTable(context.TODO(), fake, "orders-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(description.KeySchema) != 2 || len(description.GlobalSecondaryIndexes) != 1 || *description.GlobalSecondaryIndexes[0].IndexName != "byStatus" {
+		t.Errorf("got keys %v and indexes %v, want the schema of orders", description.KeySchema, description.GlobalSecondaryIndexes)
+	}
+
+	if _, err := runCommand(t, fake, "table", "create", "--file", specFile); err == nil {
+		t.Errorf("got no error creating an existing table")
+	}
+}
+
+func TestTableSpecUnknownField(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(specFile, []byte(`{"TableName": "x", "KeySchemas": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTableSpec(specFile); err == nil {
+		t.Errorf("got no error for an unknown field")
+	}
+}
+
+func TestTableUpdate(t *testing.T) {
+	fake := newOrdersFake(t)
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--billing-mode", "provisioned", "--read", "10"); err == nil {
+		t.Errorf("got no error switching to the provisioned billing mode without --write")
+	}
+	_, err := runCommand(t, fake, "table", "update", "orders", "--add-index", "byTotal=total:N,order:N", "--projection", "keys-only",
+		"--remove-index", "byStatus", "--billing-mode", "provisioned", "--read", "10", "--write", "5", "--yes", "--force")
+	if err != nil {
+		t.Fatal(err)
+	}
+	description, err := internal.DescribeTable(context.TODO(), fake, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(description.GlobalSecondaryIndexes) != 1 || *description.GlobalSecondaryIndexes[0].IndexName != "byTotal" ||
+		description.GlobalSecondaryIndexes[0].Projection.ProjectionType != types.ProjectionTypeKeysOnly {
+		t.Errorf("got indexes %+v, want byTotal only", description.GlobalSecondaryIndexes)
+	}
+	if !slices.ContainsFunc(description.AttributeDefinitions, func(definition types.AttributeDefinition) bool {
+		return *definition.AttributeName == "total" && definition.AttributeType == types.ScalarAttributeTypeN
+	}) {
+		t.Errorf("got attribute definitions %+v, want total", description.AttributeDefinitions)
+	}
+	if description.BillingModeSummary.BillingMode != types.BillingModeProvisioned || aws.ToInt64(description.ProvisionedThroughput.ReadCapacityUnits) != 10 {
+		t.Errorf("got billing mode %s with %+v", description.BillingModeSummary.BillingMode, description.ProvisionedThroughput)
+	}
+
+	// the read units are kept and the index is left alone
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--write", "7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--index-read", "20"); err == nil {
+		t.Errorf("got no error for --index-read without --index")
+	}
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--index", "byTotal", "--index-read", "20"); err != nil {
+		t.Fatal(err)
+	}
+	if description, err = internal.DescribeTable(context.TODO(), fake, "orders"); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name        string
+		throughput  *types.ProvisionedThroughputDescription
+		read, write int64
+	}{
+		{"table", description.ProvisionedThroughput, 10, 7},
+		{"index", description.GlobalSecondaryIndexes[0].ProvisionedThroughput, 20, 5},
+	} {
+		if read, write := aws.ToInt64(test.throughput.ReadCapacityUnits), aws.ToInt64(test.throughput.WriteCapacityUnits); read != test.read || write != test.write {
+			t.Errorf("got %s throughput %d/%d want %d/%d", test.name, read, write, test.read, test.write)
+		}
+	}
+}
+
+func TestTableDelete(t *testing.T) {
+	fake := newOrdersFake(t)
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--deletion-protection"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "table", "delete", "orders", "--yes", "--force"); err == nil {
+		t.Errorf("got no error deleting a protected table")
+	}
+	if _, err := runCommand(t, fake, "table", "update", "orders", "--deletion-protection=false"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "table", "delete", "orders"); err == nil {
+		t.Errorf("got no error deleting without confirmation")
+	}
+	// tests have no terminal on stdin, which destructive operations need unless --force
+	if _, err := runCommand(t, fake, "table", "delete", "orders", "--yes"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("got %v deleting without a terminal, want an error asking for --force", err)
+	}
+	if _, err := runCommand(t, fake, "table", "delete", "orders", "--yes", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := internal.DescribeTable(context.TODO(), fake, "orders"); err == nil {
+		t.Errorf("got the table after deleting it")
+	}
+}
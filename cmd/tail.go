@@ -0,0 +1,227 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// tailCmd represents the tail command
+var tailCmd = &cobra.Command{
+	Use:   "tail <table>",
+	Short: "print the changes of a table from its stream",
+	Long: `Print the changes of a table as they happen, read from the stream of the
+table, with the old and new image and the changed attributes of each change.
+The table must have a stream, the images present depend on its view type.
+
+  ddb tail orders
+  ddb tail orders --from trim-horizon --follow=false
+  ddb tail orders --event modify,remove --key customer=a`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTail,
+}
+
+// tailFilter selects the stream records to print
+type tailFilter struct {
+	events []streamstypes.OperationType
+	keys   map[string]string
+}
+
+func runTail(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	options, err := streamOptions(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := parseTailFilter(cmd)
+	if err != nil {
+		return err
+	}
+	if err := setupStreamsClient(cmd.Context()); err != nil {
+		return err
+	}
+	streamArn, err := internal.StreamArn(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	logger.Debug("reading stream " + streamArn)
+
+	err = printRecords(renderChanges(internal.ReadStream(cmd.Context(), streamsClient, streamArn, options), filter))
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// interrupting or timing out is how following a stream ends
+		return nil
+	}
+	return err
+}
+
+// setupStreamsClient creates the streams client unless already set, e.g. to a fake in tests
+func setupStreamsClient(ctx context.Context) error {
+	if streamsClient != nil {
+		return nil
+	}
+	options, err := clientOptions()
+	if err != nil {
+		return err
+	}
+	streamsClient, err = internal.StreamsClient(ctx, options)
+	return err
+}
+
+// streamOptions reads the --from, --follow and --poll-interval flags
+func streamOptions(cmd *cobra.Command) (internal.StreamOptions, error) {
+	from, err := cmd.Flags().GetString("from")
+	if err != nil {
+		return internal.StreamOptions{}, err
+	}
+	options := internal.StreamOptions{}
+	switch strings.ToLower(from) {
+	case "latest":
+		options.From = streamstypes.ShardIteratorTypeLatest
+	case "trim-horizon":
+		options.From = streamstypes.ShardIteratorTypeTrimHorizon
+	default:
+		return internal.StreamOptions{}, fmt.Errorf("invalid --from %q, expected latest or trim-horizon", from)
+	}
+	if options.Follow, err = cmd.Flags().GetBool("follow"); err != nil {
+		return internal.StreamOptions{}, err
+	}
+	if options.PollInterval, err = cmd.Flags().GetDuration("poll-interval"); err != nil {
+		return internal.StreamOptions{}, err
+	}
+	return options, nil
+}
+
+func parseTailFilter(cmd *cobra.Command) (tailFilter, error) {
+	events, err := cmd.Flags().GetStringSlice("event")
+	if err != nil {
+		return tailFilter{}, err
+	}
+	keyArgs, err := cmd.Flags().GetStringArray("key")
+	if err != nil {
+		return tailFilter{}, err
+	}
+	filter := tailFilter{keys: make(map[string]string)}
+	for _, event := range events {
+		event := streamstypes.OperationType(strings.ToUpper(event))
+		if !slices.Contains(event.Values(), event) {
+			return tailFilter{}, fmt.Errorf("invalid event %q, expected insert, modify or remove", event)
+		}
+		filter.events = append(filter.events, event)
+	}
+	for _, keyArg := range keyArgs {
+		name, value, found := strings.Cut(keyArg, "=")
+		if !found || name == "" {
+			return tailFilter{}, fmt.Errorf("invalid key %q, expected name=value", keyArg)
+		}
+		filter.keys[name] = value
+	}
+	return filter, nil
+}
+
+func (filter tailFilter) matches(record internal.ChangeRecord, keys map[string]any) bool {
+	if len(filter.events) > 0 && !slices.Contains(filter.events, record.EventName) {
+		return false
+	}
+	for name, value := range filter.keys {
+		if keyValue, found := keys[name]; !found || fmt.Sprint(keyValue) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// renderChanges unmarshals the matching stream records with the attributes changed between their images
+func renderChanges(records iter.Seq2[internal.ChangeRecord, error], filter tailFilter) iter.Seq2[map[string]any, error] {
+	return func(yield func(map[string]any, error) bool) {
+		for record, err := range records {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			change, err := unmarshalChange(record)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !filter.matches(record, change["keys"].(map[string]any)) {
+				continue
+			}
+			if !yield(change, nil) {
+				return
+			}
+		}
+	}
+}
+
+func unmarshalChange(record internal.ChangeRecord) (map[string]any, error) {
+	change := map[string]any{
+		"event":          record.EventName,
+		"time":           record.Time.UTC().Format(time.RFC3339),
+		"sequenceNumber": record.SequenceNumber,
+	}
+	var oldImage, newImage map[string]any
+	for _, image := range []struct {
+		name string
+		item internal.Item
+		to   *map[string]any
+	}{
+		{"keys", record.Keys, nil},
+		{"old", record.OldImage, &oldImage},
+		{"new", record.NewImage, &newImage},
+	} {
+		if image.item == nil && image.name != "keys" {
+			continue
+		}
+		unmarshalled, err := internal.UnmarshalItem(image.item)
+		if err != nil {
+			return nil, err
+		}
+		change[image.name] = unmarshalled
+		if image.to != nil {
+			*image.to = unmarshalled
+		}
+	}
+	if oldImage != nil && newImage != nil {
+		change["diff"] = diffImages(oldImage, newImage)
+	}
+	return change, nil
+}
+
+// diffImages returns the old and new value of every attribute added, removed or changed
+func diffImages(oldImage, newImage map[string]any) map[string]any {
+	diff := make(map[string]any)
+	for name, oldValue := range oldImage {
+		if newValue, found := newImage[name]; !found || !reflect.DeepEqual(oldValue, newValue) {
+			diff[name] = map[string]any{"old": oldValue, "new": newValue}
+		}
+	}
+	for name, newValue := range newImage {
+		if _, found := oldImage[name]; !found {
+			diff[name] = map[string]any{"old": nil, "new": newValue}
+		}
+	}
+	return diff
+}
+
+func init() {
+	rootCmd.AddCommand(tailCmd)
+
+	tailCmd.Flags().String("from", "latest", "where to start reading shards, latest or trim-horizon (the last 24 hours)")
+	tailCmd.Flags().Bool("follow", true, "keep waiting for changes, otherwise stop at the end of the stream")
+	tailCmd.Flags().Duration("poll-interval", time.Second, "pause between reads once the end of the stream is reached")
+	tailCmd.Flags().StringSlice("event", []string{}, "changes to print, insert, modify and/or remove (default all)")
+	tailCmd.Flags().StringArray("key", []string{}, "only print changes of items with the key attribute value, name=value")
+}
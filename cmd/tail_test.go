@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// enableStream enables the stream of the orders table and changes order a/1
+// then deletes order b/3
+func enableStream(t *testing.T, fake *internal.FakeDynamodb) {
+	t.Helper()
+	_, err := fake.UpdateTable(context.TODO(), &dynamodb.UpdateTableInput{
+		TableName:           aws.String("orders"),
+		StreamSpecification: &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := internal.MarshalItem(map[string]any{"customer": "a", "order": 1, "status": "shipped", "total": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.PutItem(context.TODO(), fake, "orders", item); err != nil {
+		t.Fatal(err)
+	}
+	key, err := internal.MarshalItem(map[string]any{"customer": "b", "order": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.DeleteItem(context.TODO(), fake, "orders", key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	fake := newOrdersFake(t)
+	if _, err := runCommand(t, fake, "tail", "orders", "--follow=false"); err == nil {
+		t.Errorf("got no error without a stream")
+	}
+	enableStream(t, fake)
+
+	output, err := runCommand(t, fake, "tail", "orders", "--from", "trim-horizon", "--follow=false", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d changes, want 2\n%s", len(lines), output)
+	}
+	var modify map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &modify); err != nil {
+		t.Fatal(err)
+	}
+	diff, _ := json.Marshal(modify["diff"])
+	if modify["event"] != "MODIFY" || string(diff) != `{"status":{"new":"shipped","old":"open"}}` {
+		t.Errorf("got %s, want a MODIFY of status", lines[0])
+	}
+
+	output, err = runCommand(t, fake, "tail", "orders", "--from", "trim-horizon", "--follow=false", "--event", "remove", "--key", "order=3", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, `"event":"REMOVE"`) || strings.Count(output, "\n") != 1 {
+		t.Errorf("got %q, want the REMOVE of order 3", output)
+	}
+
+	if output, err := runCommand(t, fake, "tail", "orders", "--follow=false"); err != nil || output != "" {
+		t.Errorf("got %q, %v want no changes from latest", output, err)
+	}
+	if _, err := runCommand(t, fake, "tail", "orders", "--event", "update"); err == nil {
+		t.Errorf("got no error for an invalid event")
+	}
+}
@@ -0,0 +1,296 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+type transactOperation struct {
+	Op        string         `yaml:"op"`
+	Table     string         `yaml:"table"`
+	Key       []string       `yaml:"key"`
+	Item      map[string]any `yaml:"item"`
+	Set       []string       `yaml:"set"`
+	Remove    []string       `yaml:"remove"`
+	Condition []string       `yaml:"condition"`
+}
+
+// transactCmd represents the transact command
+var transactCmd = &cobra.Command{
+	Use:   "transact <file>",
+	Short: "write items in a transaction",
+	Long: `Apply a list of put, update, delete and condition_check operations from a
+YAML or JSON file as a single all-or-nothing transaction.
+
+Keys are given as one value per key like the get command, conditions use
+the filter syntax, and update assignments are field=value.
+
+Example:
+  - op: put
+    table: orders
+    item: {pk: X, sk: 2024-01, status: new}
+  - op: update
+    table: orders
+    key: [X, 2024-02]
+    set: [status=shipped]
+    remove: [eta]
+    condition: [status=new]
+  - op: delete
+    table: orders
+    key: [X, 2023-12]
+  - op: condition_check
+    table: customers
+    key: [X]
+    condition: [active=1]`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTransact,
+}
+
+func runTransact(cmd *cobra.Command, args []string) error {
+	contents, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read transaction file: %w", err)
+	}
+	var operations []transactOperation
+	if err := yaml.Unmarshal(contents, &operations); err != nil {
+		return fmt.Errorf("failed to parse transaction file [%w]", err)
+	}
+	if len(operations) == 0 || len(operations) > internal.TransactWriteLimit {
+		return fmt.Errorf("a transaction needs between 1 and %d operations, %d were provided", internal.TransactWriteLimit, len(operations))
+	}
+
+	tableKeys := make(map[string][]internal.Key)
+	var transactItems []types.TransactWriteItem
+	for index := range operations {
+		operation := &operations[index]
+		// without a table buildTransactItem fails before anything is described
+		var keys []internal.Key
+		if operation.Table != "" {
+			operation.Table = resolveTableName(operation.Table)
+			var found bool
+			if keys, found = tableKeys[operation.Table]; !found {
+				logger.Debug(fmt.Sprintf("describing table %s", operation.Table))
+				keys, err = internal.GetTableKeys(cmd.Context(), client, operation.Table)
+				if err != nil {
+					return fmt.Errorf("operation %d: failed to get table keys: %w", index+1, err)
+				}
+				tableKeys[operation.Table] = keys
+			}
+		}
+		transactItem, err := buildTransactItem(*operation, keys)
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", index+1, err)
+		}
+		transactItems = append(transactItems, transactItem)
+	}
+
+	access := WriteAccess
+	var tableNames []string
+	counts := make(map[string]int)
+	var ops []string
+	for _, operation := range operations {
+		if operation.Op == "delete" {
+			access = DestructiveAccess
+		}
+		if !slices.Contains(tableNames, operation.Table) {
+			tableNames = append(tableNames, operation.Table)
+		}
+		if counts[operation.Op] == 0 {
+			ops = append(ops, operation.Op)
+		}
+		counts[operation.Op]++
+	}
+	var summary []string
+	for _, op := range ops {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[op], op))
+	}
+	preview := fmt.Sprintf("apply transaction of %s", strings.Join(summary, ", "))
+	if err := guardWrite(access, preview, len(operations), "items", tableNames...); err != nil {
+		return err
+	}
+
+	logger.Debug(fmt.Sprintf("running transaction of %d operations", len(transactItems)))
+	err = internal.TransactWriteItems(cmd.Context(), client, transactItems)
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for index, reason := range canceled.CancellationReasons {
+			if code := aws.ToString(reason.Code); code != "None" {
+				operation := operations[index]
+				logger.Error(fmt.Sprintf("operation %d (%s %s) failed: %s %s", index+1, operation.Op, operation.Table, code, aws.ToString(reason.Message)))
+			}
+		}
+		return fmt.Errorf("transaction canceled, no changes were made")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write transaction: %w", err)
+	}
+	logger.Info(fmt.Sprintf("transaction of %d operations committed", len(transactItems)))
+	return nil
+}
+
+// buildTransactItem validates an operation against the table's keys and converts it to a TransactWriteItem
+func buildTransactItem(operation transactOperation, keys []internal.Key) (types.TransactWriteItem, error) {
+	if operation.Table == "" {
+		return types.TransactWriteItem{}, fmt.Errorf("table is required")
+	}
+	if operation.Op != "put" && operation.Item != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("item is only valid for put, use key for %s", operation.Op)
+	}
+	if operation.Op != "update" && (len(operation.Set) > 0 || len(operation.Remove) > 0) {
+		return types.TransactWriteItem{}, fmt.Errorf("set and remove are only valid for update")
+	}
+
+	var keyValues internal.Item
+	if operation.Op == "put" {
+		if len(operation.Key) > 0 {
+			return types.TransactWriteItem{}, fmt.Errorf("key is not valid for put, include the keys in item")
+		}
+		item, err := internal.MarshalItem(operation.Item)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+		if err := validateItemKeys(operation.Table, keys, item); err != nil {
+			return types.TransactWriteItem{}, err
+		}
+		keyValues = item
+	} else {
+		var err error
+		keyValues, err = buildKeyValues(operation.Table, keys, operation.Key)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+	}
+
+	builder := expression.NewBuilder()
+	hasExpression := false
+	if len(operation.Condition) > 0 {
+		condition, err := buildFilterCondition(operation.Condition)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+		builder = builder.WithCondition(condition)
+		hasExpression = true
+	}
+	if operation.Op == "update" {
+		if len(operation.Set) == 0 && len(operation.Remove) == 0 {
+			return types.TransactWriteItem{}, fmt.Errorf("update requires set or remove")
+		}
+		update, err := buildUpdate(operation.Set, operation.Remove)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+		builder = builder.WithUpdate(update)
+		hasExpression = true
+	}
+	var expr expression.Expression
+	if hasExpression {
+		var err error
+		expr, err = builder.Build()
+		if err != nil {
+			return types.TransactWriteItem{}, fmt.Errorf("failed to build expression [%w]", err)
+		}
+	}
+
+	switch operation.Op {
+	case "put":
+		return types.TransactWriteItem{Put: &types.Put{
+			TableName:                 &operation.Table,
+			Item:                      keyValues,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}}, nil
+	case "update":
+		return types.TransactWriteItem{Update: &types.Update{
+			TableName:                 &operation.Table,
+			Key:                       keyValues,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}}, nil
+	case "delete":
+		return types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                 &operation.Table,
+			Key:                       keyValues,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}}, nil
+	case "condition_check":
+		if !hasExpression {
+			return types.TransactWriteItem{}, fmt.Errorf("condition_check requires a condition")
+		}
+		return types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+			TableName:                 &operation.Table,
+			Key:                       keyValues,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}}, nil
+	}
+	return types.TransactWriteItem{}, fmt.Errorf("unknown op %q, expected put, update, delete or condition_check", operation.Op)
+}
+
+// buildUpdate converts field=value assignments and attribute names to remove into an update expression
+func buildUpdate(assignments []string, removals []string) (expression.UpdateBuilder, error) {
+	var update expression.UpdateBuilder
+	for _, assignment := range assignments {
+		field, value, operator := ParseArg(assignment)
+		if field == "" || operator != Equal {
+			return update, fmt.Errorf("assignment %s must be of the form field=value", assignment)
+		}
+		value, valueType := ParseArgValue(value)
+		attributeValue, err := internal.MarshalArgument(value, valueType)
+		if err != nil {
+			return update, fmt.Errorf("failed to marshal assignment %s with value %s to inferred type %s [%w]", assignment, value, valueType, err)
+		}
+		update = update.Set(expression.Name(field), expression.Value(attributeValue))
+	}
+	for _, field := range removals {
+		update = update.Remove(expression.Name(field))
+	}
+	return update, nil
+}
+
+// validateItemKeys checks an item has every key attribute with the type from the key schema
+func validateItemKeys(tableName string, keys []internal.Key, item internal.Item) error {
+	for _, key := range keys {
+		value, found := item[key.Name]
+		if !found {
+			return fmt.Errorf("item is missing key %s of table %s", key.Name, tableName)
+		}
+		var valueType types.ScalarAttributeType
+		switch value.(type) {
+		case *types.AttributeValueMemberS:
+			valueType = types.ScalarAttributeTypeS
+		case *types.AttributeValueMemberN:
+			valueType = types.ScalarAttributeTypeN
+		case *types.AttributeValueMemberB:
+			valueType = types.ScalarAttributeTypeB
+		}
+		if valueType != key.AttributeType {
+			return fmt.Errorf("key %s of table %s must have type %s", key.Name, tableName, key.AttributeType)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(transactCmd)
+}
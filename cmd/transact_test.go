@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestBuildTransactItem(t *testing.T) {
+	keys := []internal.Key{
+		{Name: "pk", KeyType: types.KeyTypeHash, AttributeType: types.ScalarAttributeTypeS},
+		{Name: "sk", KeyType: types.KeyTypeRange, AttributeType: types.ScalarAttributeTypeN},
+	}
+	var tests = []struct {
+		name, operation string
+		valid           bool
+	}{
+		{"put", "{op: put, table: t, item: {pk: a, sk: 1, status: new}}", true},
+		{"putMissingKey", "{op: put, table: t, item: {pk: a}}", false},
+		{"putWrongKeyType", "{op: put, table: t, item: {pk: a, sk: b}}", false},
+		{"putWithKey", "{op: put, table: t, key: [a, 1], item: {pk: a, sk: 1}}", false},
+		{"update", "{op: update, table: t, key: [a, 1], set: [status=done], remove: [eta], condition: [status=new]}", true},
+		{"updateWithoutChanges", "{op: update, table: t, key: [a, 1]}", false},
+		{"updateBadAssignment", "{op: update, table: t, key: [a, 1], set: [status>done]}", false},
+		{"delete", "{op: delete, table: t, key: [a, 1]}", true},
+		{"deleteMissingSortKey", "{op: delete, table: t, key: [a]}", false},
+		{"deleteWithSet", "{op: delete, table: t, key: [a, 1], set: [status=done]}", false},
+		{"conditionCheck", "{op: condition_check, table: t, key: [a, 1], condition: [version=3]}", true},
+		{"conditionCheckWithoutCondition", "{op: condition_check, table: t, key: [a, 1]}", false},
+		{"unknownOp", "{op: upsert, table: t, key: [a, 1]}", false},
+		{"missingTable", "{op: delete, key: [a, 1]}", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var operation transactOperation
+			if err := yaml.Unmarshal([]byte(test.operation), &operation); err != nil {
+				t.Fatal(err)
+			}
+			_, err := buildTransactItem(operation, keys)
+			if (err == nil) != test.valid {
+				t.Errorf("got error %v want valid %t", err, test.valid)
+			}
+		})
+	}
+}
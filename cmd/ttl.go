@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+// ttlCmd represents the ttl command
+var ttlCmd = &cobra.Command{
+	Use:   "ttl <table> [enable <attribute>|disable]",
+	Short: "show, enable or disable time to live",
+	Long: `Show the time to live attribute and status of a table, or enable or disable
+time to live. Items expire when their time to live attribute, in epoch seconds,
+is in the past.
+
+  ddb ttl orders
+  ddb ttl orders enable expiresAt
+  ddb ttl orders disable`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.RangeArgs(1, 3)(cmd, args); err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			return nil
+		}
+		switch {
+		case args[1] == "enable" && len(args) == 3:
+			return nil
+		case args[1] == "disable" && len(args) == 2:
+			return nil
+		}
+		return fmt.Errorf("expected enable <attribute> or disable, got %v", args[1:])
+	},
+	RunE: runTtl,
+}
+
+// expiringCmd represents the expiring command
+var expiringCmd = &cobra.Command{
+	Use:   "expiring <table>",
+	Short: "list items due to expire",
+	Long: `Scan a table for items whose time to live is within the given duration,
+including expired items dynamodb hasn't deleted yet, and print them with the
+time to live as a timestamp.
+
+  ddb expiring orders --within 24h`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExpiring,
+}
+
+func runTtl(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	attributeName, status, err := internal.TimeToLiveAttribute(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		if attributeName == "" {
+			fmt.Println(status)
+		} else {
+			fmt.Printf("%s\t%s\n", status, attributeName)
+		}
+		return nil
+	}
+
+	enable := args[1] == "enable"
+	if enable {
+		attributeName = args[2]
+	} else if attributeName == "" {
+		return fmt.Errorf("time to live of %s is already %s", tableName, status)
+	}
+	if err := guardTableWrite(WriteAccess, fmt.Sprintf("%s time to live of %s", args[1], tableName), tableName); err != nil {
+		return err
+	}
+	if err := internal.UpdateTimeToLive(cmd.Context(), client, tableName, attributeName, enable); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("time to live of %s on %s is %sd", tableName, attributeName, args[1]))
+	return nil
+}
+
+func runExpiring(cmd *cobra.Command, args []string) error {
+	tableName := resolveTableName(args[0])
+	within, err := cmd.Flags().GetDuration("within")
+	if err != nil {
+		return err
+	}
+	attributeName, status, err := internal.TimeToLiveAttribute(cmd.Context(), client, tableName)
+	if err != nil {
+		return err
+	}
+	if attributeName == "" || status != types.TimeToLiveStatusEnabled {
+		return fmt.Errorf("time to live of %s is %s", tableName, status)
+	}
+
+	deadline := time.Now().Add(within).Unix()
+	scanInput, err := buildExpiringScanInput(tableName, attributeName, deadline, viper.GetStringSlice("filter"))
+	if err != nil {
+		return err
+	}
+	return printItems(renderExpiry(internal.IterateScan(cmd.Context(), client, scanInput), attributeName))
+}
+
+// buildExpiringScanInput scans for items with a time to live up to the deadline in epoch seconds
+func buildExpiringScanInput(tableName, attributeName string, deadline int64, filterArgs []string) (dynamodb.ScanInput, error) {
+	filterCondition := expression.Name(attributeName).LessThanEqual(expression.Value(deadline))
+	if len(filterArgs) > 0 {
+		extraCondition, err := buildFilterCondition(filterArgs)
+		if err != nil {
+			return dynamodb.ScanInput{}, err
+		}
+		filterCondition = filterCondition.And(extraCondition)
+	}
+	expr, err := expression.NewBuilder().WithFilter(filterCondition).Build()
+	if err != nil {
+		return dynamodb.ScanInput{}, fmt.Errorf("failed to build scan expression [%w]", err)
+	}
+	return dynamodb.ScanInput{
+		TableName:                 &tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+	}, nil
+}
+
+// renderExpiry replaces the epoch seconds time to live of every item with a timestamp
+func renderExpiry(items iter.Seq2[internal.Item, error], attributeName string) iter.Seq2[internal.Item, error] {
+	return func(yield func(internal.Item, error) bool) {
+		for item, err := range items {
+			if number, ok := item[attributeName].(*types.AttributeValueMemberN); ok && err == nil {
+				if seconds, parseErr := strconv.ParseInt(number.Value, 10, 64); parseErr == nil {
+					item[attributeName] = &types.AttributeValueMemberS{Value: time.Unix(seconds, 0).UTC().Format(time.RFC3339)}
+				}
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(ttlCmd)
+	rootCmd.AddCommand(expiringCmd)
+
+	expiringCmd.Flags().Duration("within", 24*time.Hour, "how soon items expire")
+}
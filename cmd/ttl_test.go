@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestTtl(t *testing.T) {
+	fake := newOrdersFake(t)
+	if output, err := runCommand(t, fake, "ttl", "orders"); err != nil || strings.TrimSpace(output) != "DISABLED" {
+		t.Errorf("got %q, %v want DISABLED", output, err)
+	}
+	if _, err := runCommand(t, fake, "ttl", "orders", "enable", "expiresAt"); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := runCommand(t, fake, "ttl", "orders"); err != nil || strings.TrimSpace(output) != "ENABLED\texpiresAt" {
+		t.Errorf("got %q, %v want ENABLED expiresAt", output, err)
+	}
+	if _, err := runCommand(t, fake, "ttl", "orders", "disable"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, fake, "ttl", "orders", "disable"); err == nil {
+		t.Errorf("got no error disabling a disabled ttl")
+	}
+	if _, err := runCommand(t, fake, "ttl", "orders", "enable"); err == nil {
+		t.Errorf("got no error enabling without an attribute")
+	}
+}
+
+func TestExpiring(t *testing.T) {
+	fake := newOrdersFake(t)
+	if _, err := runCommand(t, fake, "expiring", "orders"); err == nil {
+		t.Errorf("got no error without ttl")
+	}
+	if err := internal.UpdateTimeToLive(context.TODO(), fake, "orders", "expiresAt", true); err != nil {
+		t.Fatal(err)
+	}
+	soon := time.Now().Add(time.Hour).Unix()
+	for _, order := range []map[string]any{
+		{"customer": "c", "order": 4, "expiresAt": soon},
+		{"customer": "c", "order": 5, "expiresAt": time.Now().Add(48 * time.Hour).Unix()},
+	} {
+		item, err := internal.MarshalItem(order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := internal.PutItem(context.TODO(), fake, "orders", item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output, err := runCommand(t, fake, "expiring", "orders", "--within", "24h", "--pretty=false", "--color=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"customer":"c","expiresAt":"` + time.Unix(soon, 0).UTC().Format(time.RFC3339) + `","order":4}`
+	if output = strings.TrimSpace(output); output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+}
@@ -0,0 +1,473 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+const (
+	tuiPageSize  = 50 // items loaded into the grid per page
+	tuiCellWidth = 40 // maximum width of a grid cell
+	tuiHelp      = "tab: switch pane  enter: select  e: edit  d: delete  esc: back  ctrl-c: quit"
+)
+
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui [table]",
+	Short: "browse tables",
+	Long: `Browse dynamodb tables in a full-screen terminal UI.
+
+Select a table to scan it, or fill in the partition (and optionally sort)
+input to query it instead. Sort and filter inputs use the same syntax as the
+query command, multiple filters are separated by commas.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTui,
+}
+
+type tableBrowser struct {
+	ctx   context.Context
+	app   *tview.Application
+	pages *tview.Pages
+
+	tables         *tview.List
+	indexInput     *tview.InputField
+	partitionInput *tview.InputField
+	sortInput      *tview.InputField
+	filterInput    *tview.InputField
+	grid           *tview.Table
+	status         *tview.TextView
+	detail         *tview.TextView
+	editor         *tview.TextArea
+
+	tableName string
+	keys      []internal.Key
+	columns   []string
+	items     []internal.Item
+	rows      []map[string]any
+	selected  int
+
+	next    func() (internal.Item, error, bool)
+	stop    func()
+	loading bool
+}
+
+func runTui(cmd *cobra.Command, args []string) error {
+	// log output would draw over the terminal UI
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tableNames, err := internal.ListTables(cmd.Context(), client)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	browser := newTableBrowser(cmd.Context())
+	for _, tableName := range tableNames {
+		browser.tables.AddItem(tableName, "", 0, nil)
+	}
+	if len(args) == 1 {
+		browser.selectTable(resolveTableName(args[0]))
+	}
+	return browser.app.Run()
+}
+
+func newTableBrowser(ctx context.Context) *tableBrowser {
+	b := &tableBrowser{
+		ctx:            ctx,
+		app:            tview.NewApplication(),
+		pages:          tview.NewPages(),
+		tables:         tview.NewList().ShowSecondaryText(false),
+		indexInput:     tview.NewInputField().SetLabel("index: "),
+		partitionInput: tview.NewInputField().SetLabel("partition: "),
+		sortInput:      tview.NewInputField().SetLabel("sort: "),
+		filterInput:    tview.NewInputField().SetLabel("filter: "),
+		grid:           tview.NewTable().SetFixed(1, 0).SetSelectable(true, false),
+		status:         tview.NewTextView().SetText(tuiHelp),
+		detail:         tview.NewTextView().SetDynamicColors(true).SetScrollable(true),
+		editor:         tview.NewTextArea(),
+	}
+
+	b.tables.SetBorder(true).SetTitle("tables")
+	b.tables.SetSelectedFunc(func(_ int, tableName string, _ string, _ rune) {
+		b.selectTable(tableName)
+	})
+
+	for _, input := range []*tview.InputField{b.indexInput, b.partitionInput, b.sortInput, b.filterInput} {
+		input.SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter {
+				b.search()
+			}
+		})
+	}
+
+	b.grid.SetBorder(true)
+	b.grid.SetSelectionChangedFunc(func(row, _ int) {
+		// the header is row 0 so the last item is at len(b.rows)
+		if row >= len(b.rows) && b.next != nil {
+			b.loadPage()
+		}
+	})
+	b.grid.SetSelectedFunc(func(row, _ int) {
+		if row > 0 {
+			b.showDetail(row - 1)
+		}
+	})
+
+	b.detail.SetBorder(true).SetTitle("item")
+	b.detail.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			b.pages.SwitchToPage("main")
+		case event.Rune() == 'e':
+			b.showEditor()
+		case event.Rune() == 'd':
+			b.confirmDelete()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	b.editor.SetBorder(true).SetTitle("edit item as dynamodb json (ctrl-s: save, esc: cancel)")
+	b.editor.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			b.pages.SwitchToPage("detail")
+		case tcell.KeyCtrlS:
+			b.confirmSave()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	inputs := tview.NewFlex().
+		AddItem(b.indexInput, 0, 1, false).
+		AddItem(b.partitionInput, 0, 1, false).
+		AddItem(b.sortInput, 0, 1, false).
+		AddItem(b.filterInput, 0, 2, false)
+	results := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(inputs, 1, 0, false).
+		AddItem(b.grid, 0, 1, false).
+		AddItem(b.status, 1, 0, false)
+	main := tview.NewFlex().
+		AddItem(b.tables, 30, 0, true).
+		AddItem(results, 0, 1, false)
+
+	b.pages.AddPage("main", main, true, true)
+	b.pages.AddPage("detail", b.detail, true, false)
+	b.pages.AddPage("editor", b.editor, true, false)
+
+	b.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if page, _ := b.pages.GetFrontPage(); page != "main" {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyTab:
+			b.cycleFocus(1)
+		case tcell.KeyBacktab:
+			b.cycleFocus(-1)
+		default:
+			return event
+		}
+		return nil
+	})
+	b.app.SetRoot(b.pages, true)
+	return b
+}
+
+func (b *tableBrowser) cycleFocus(step int) {
+	panes := []tview.Primitive{b.tables, b.indexInput, b.partitionInput, b.sortInput, b.filterInput, b.grid}
+	current := slices.Index(panes, b.app.GetFocus())
+	b.app.SetFocus(panes[(current+step+len(panes))%len(panes)])
+}
+
+func (b *tableBrowser) selectTable(tableName string) {
+	if b.loading {
+		return
+	}
+	keys, err := internal.GetTableKeys(b.ctx, client, tableName)
+	if err != nil {
+		b.showError(fmt.Errorf("failed to get table keys: %w", err))
+		return
+	}
+	b.tableName = tableName
+	b.keys = keys
+	b.grid.SetTitle(tableName)
+	for _, input := range []*tview.InputField{b.indexInput, b.partitionInput, b.sortInput, b.filterInput} {
+		input.SetText("")
+	}
+	b.search()
+	b.app.SetFocus(b.grid)
+}
+
+// search scans the selected table, or queries it when a partition value was entered
+func (b *tableBrowser) search() {
+	if b.tableName == "" || b.loading {
+		return
+	}
+	var filterArgs []string
+	for _, filterArg := range strings.Split(b.filterInput.GetText(), ",") {
+		if filterArg = strings.TrimSpace(filterArg); filterArg != "" {
+			filterArgs = append(filterArgs, filterArg)
+		}
+	}
+	indexName := strings.TrimSpace(b.indexInput.GetText())
+
+	var items iter.Seq2[internal.Item, error]
+	if partitionValue := b.partitionInput.GetText(); partitionValue != "" {
+		args := queryArgs{
+			tableName:      b.tableName,
+			indexName:      indexName,
+			partitionValue: partitionValue,
+		}
+		_, args.sortValue, args.sortOperator = ParseArg(b.sortInput.GetText())
+		queryInput, err := buildQueryInput(b.ctx, args, filterArgs)
+		if err != nil {
+			b.showError(err)
+			return
+		}
+		items = internal.IterateQuery(b.ctx, client, queryInput)
+	} else {
+		scanInput, err := buildScanInput(b.tableName, filterArgs, nil)
+		if err != nil {
+			b.showError(err)
+			return
+		}
+		if indexName != "" {
+			scanInput.IndexName = &indexName
+		}
+		items = internal.IterateScan(b.ctx, client, scanInput)
+	}
+
+	if b.stop != nil {
+		b.stop()
+	}
+	b.columns = nil
+	for _, key := range b.keys {
+		b.columns = append(b.columns, key.Name)
+	}
+	b.items, b.rows = nil, nil
+	b.next, b.stop = iter.Pull2(items)
+	b.renderGrid()
+	b.loadPage()
+}
+
+// loadPage pulls the next page of items in the background and appends it to the grid
+func (b *tableBrowser) loadPage() {
+	if b.next == nil || b.loading {
+		return
+	}
+	b.loading = true
+	b.status.SetText("loading...")
+	next := b.next
+	go func() {
+		var page []internal.Item
+		var err error
+		exhausted := false
+		for len(page) < tuiPageSize {
+			item, itemErr, ok := next()
+			if !ok || itemErr != nil {
+				err = itemErr
+				exhausted = true
+				break
+			}
+			page = append(page, item)
+		}
+		b.app.QueueUpdateDraw(func() {
+			b.loading = false
+			if exhausted {
+				b.stop()
+				b.next = nil
+			}
+			if appendErr := b.appendItems(page); appendErr != nil {
+				err = appendErr
+			}
+			if err != nil {
+				b.showError(err)
+			}
+			b.status.SetText(fmt.Sprintf("%d items  %s", len(b.items), tuiHelp))
+		})
+	}()
+}
+
+func (b *tableBrowser) appendItems(items []internal.Item) error {
+	var newColumns []string
+	for _, item := range items {
+		row, err := internal.UnmarshalItem(item)
+		if err != nil {
+			return err
+		}
+		for name := range row {
+			if !slices.Contains(b.columns, name) && !slices.Contains(newColumns, name) {
+				newColumns = append(newColumns, name)
+			}
+		}
+		b.items = append(b.items, item)
+		b.rows = append(b.rows, row)
+	}
+	sort.Strings(newColumns)
+	b.columns = append(b.columns, newColumns...)
+	b.renderGrid()
+	return nil
+}
+
+func (b *tableBrowser) renderGrid() {
+	b.grid.Clear()
+	for column, name := range b.columns {
+		b.grid.SetCell(0, column, tview.NewTableCell(tview.Escape(name)).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	for row, item := range b.rows {
+		for column, name := range b.columns {
+			text := ""
+			if value, found := item[name]; found {
+				valueJson, _ := json.Marshal(value)
+				text = string(valueJson)
+			}
+			b.grid.SetCell(row+1, column, tview.NewTableCell(tview.Escape(text)).SetMaxWidth(tuiCellWidth))
+		}
+	}
+}
+
+func (b *tableBrowser) showDetail(index int) {
+	b.selected = index
+	itemJson, err := json.Marshal(b.rows[index])
+	if err != nil {
+		b.showError(fmt.Errorf("failed to marshal item as json: %w", err))
+		return
+	}
+	b.detail.SetText(ansiToTview(string(internal.FormatJson(itemJson, true, true))))
+	b.detail.ScrollToBeginning()
+	b.pages.SwitchToPage("detail")
+}
+
+// showEditor edits the item in the dynamodb json format of the api, which
+// keeps the types of the attributes, e.g. sets and binary values
+func (b *tableBrowser) showEditor() {
+	itemJson, err := json.Marshal(internal.WireItem(b.items[b.selected]))
+	if err != nil {
+		b.showError(fmt.Errorf("failed to marshal item as json: %w", err))
+		return
+	}
+	b.editor.SetText(string(internal.FormatJson(itemJson, true, false)), false)
+	b.pages.SwitchToPage("editor")
+}
+
+func (b *tableBrowser) confirmSave() {
+	if err := checkWriteAllowed(b.tableName); err != nil {
+		b.showError(err)
+		return
+	}
+	item, err := internal.ParseWireItem([]byte(b.editor.GetText()))
+	if err != nil {
+		b.showError(err)
+		return
+	}
+	message := fmt.Sprintf("Replace this item in %s?\nChanging a key attribute creates a new item.", b.tableName)
+	b.confirm(message, "editor", func() {
+		b.saveItem(item)
+	})
+}
+
+// saveItem puts the edited item and replaces the selected one with it
+func (b *tableBrowser) saveItem(item internal.Item) {
+	if err := internal.PutItem(b.ctx, client, b.tableName, item); err != nil {
+		b.showError(err)
+		return
+	}
+	row, err := internal.UnmarshalItem(item)
+	if err != nil {
+		b.showError(err)
+		return
+	}
+	b.items[b.selected] = item
+	b.rows[b.selected] = row
+	b.renderGrid()
+	b.showDetail(b.selected)
+}
+
+func (b *tableBrowser) confirmDelete() {
+	if err := checkWriteAllowed(b.tableName); err != nil {
+		b.showError(err)
+		return
+	}
+	keyValues, err := internal.KeyValues(b.keys, b.items[b.selected])
+	if err != nil {
+		b.showError(err)
+		return
+	}
+	b.confirm(fmt.Sprintf("Delete this item from %s?", b.tableName), "detail", func() {
+		if err := internal.DeleteItem(b.ctx, client, b.tableName, keyValues); err != nil {
+			b.showError(err)
+			return
+		}
+		b.items = slices.Delete(b.items, b.selected, b.selected+1)
+		b.rows = slices.Delete(b.rows, b.selected, b.selected+1)
+		b.renderGrid()
+		b.pages.SwitchToPage("main")
+		b.app.SetFocus(b.grid)
+	})
+}
+
+func (b *tableBrowser) confirm(message string, returnPage string, action func()) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"Cancel", "Confirm"}).
+		SetDoneFunc(func(_ int, label string) {
+			b.pages.RemovePage("modal")
+			b.pages.SwitchToPage(returnPage)
+			if label == "Confirm" {
+				action()
+			}
+		})
+	b.pages.AddPage("modal", modal, false, true)
+}
+
+func (b *tableBrowser) showError(err error) {
+	returnPage, _ := b.pages.GetFrontPage()
+	modal := tview.NewModal().
+		SetText(err.Error()).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(_ int, _ string) {
+			b.pages.RemovePage("modal")
+			b.pages.SwitchToPage(returnPage)
+		})
+	b.pages.AddPage("modal", modal, false, true)
+}
+
+// ansiToTview converts colored output from internal.FormatJson into tview
+// color tags, escaping any text that tview would mistake for a tag
+func ansiToTview(text string) string {
+	var escaped strings.Builder
+	last := 0
+	for _, location := range ansiSequence.FindAllStringIndex(text, -1) {
+		escaped.WriteString(tview.Escape(text[last:location[0]]))
+		escaped.WriteString(text[location[0]:location[1]])
+		last = location[1]
+	}
+	escaped.WriteString(tview.Escape(text[last:]))
+	return tview.TranslateANSI(escaped.String())
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dajmeister/ddb/internal"
+)
+
+func TestTuiEditKeepsTypes(t *testing.T) {
+	fake := newOrdersFake(t)
+	client = fake
+	t.Cleanup(func() { client = nil })
+	item := internal.Item{
+		"customer": &types.AttributeValueMemberS{Value: "c"},
+		"order":    &types.AttributeValueMemberN{Value: "12345678901234567890123"},
+		"status":   &types.AttributeValueMemberS{Value: "open"},
+		"tags":     &types.AttributeValueMemberSS{Value: []string{"gift", "rush"}},
+		"weights":  &types.AttributeValueMemberNS{Value: []string{"0.1", "2"}},
+		"receipt":  &types.AttributeValueMemberB{Value: []byte("pdf")},
+	}
+	if err := internal.PutItem(context.TODO(), fake, "orders", item); err != nil {
+		t.Fatal(err)
+	}
+
+	browser := newTableBrowser(context.TODO())
+	browser.tableName = "orders"
+	if err := browser.appendItems([]internal.Item{item}); err != nil {
+		t.Fatal(err)
+	}
+	browser.selected = 0
+	browser.showEditor()
+	edited, err := internal.ParseWireItem([]byte(strings.Replace(browser.editor.GetText(), `"open"`, `"shipped"`, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser.saveItem(edited)
+	if page, _ := browser.pages.GetFrontPage(); page != "detail" {
+		t.Fatalf("got page %s after saving, want the detail of the item", page)
+	}
+
+	output, err := fake.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String("orders"),
+		Key:       internal.Item{"customer": item["customer"], "order": item["order"]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item["status"] = &types.AttributeValueMemberS{Value: "shipped"}
+	got, _ := json.Marshal(internal.WireItem(output.Item))
+	want, _ := json.Marshal(internal.WireItem(item))
+	if string(got) != string(want) {
+		t.Errorf("got %s want %s", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// AggregateFunctions are the functions of an Aggregation, count counts the
+// items and the others the values of a field
+var AggregateFunctions = []string{"count", "sum", "avg", "min", "max"}
+
+// Aggregation is a function over the values of a field in each group
+type Aggregation struct {
+	Function string
+	Field    string // empty for count
+}
+
+// Name is the attribute holding the result, e.g. count or sum_total
+func (aggregation Aggregation) Name() string {
+	if aggregation.Field == "" {
+		return aggregation.Function
+	}
+	return aggregation.Function + "_" + aggregation.Field
+}
+
+// Aggregator groups unmarshalled items by the values of fields and keeps only
+// the running aggregates of each group, not the items
+type Aggregator struct {
+	groupBy      []string
+	aggregations []Aggregation
+	groups       map[string]*aggregateGroup
+}
+
+type aggregateGroup struct {
+	values []any // of the group by fields
+	count  int
+	// per aggregation, sums and counts of the numbers for sum and avg, the
+	// least or greatest value for min and max
+	sums     []float64
+	numbers  []int
+	extremes []any
+}
+
+// NewAggregator validates the aggregations, every group by field and
+// aggregation names a distinct field of the results
+func NewAggregator(groupBy []string, aggregations []Aggregation) (*Aggregator, error) {
+	names := make(map[string]bool)
+	for _, field := range groupBy {
+		if names[field] {
+			return nil, fmt.Errorf("items are grouped by %s more than once", field)
+		}
+		names[field] = true
+	}
+	for _, aggregation := range aggregations {
+		if !slices.Contains(AggregateFunctions, aggregation.Function) {
+			return nil, fmt.Errorf("invalid aggregate function %s, expected one of %s", aggregation.Function, strings.Join(AggregateFunctions, ", "))
+		}
+		if (aggregation.Field == "") != (aggregation.Function == "count") {
+			return nil, fmt.Errorf("invalid aggregation %s of %q", aggregation.Function, aggregation.Field)
+		}
+		if slices.Contains(groupBy, aggregation.Name()) {
+			return nil, fmt.Errorf("group by field %s has the name of the aggregate %s of %q", aggregation.Name(), aggregation.Function, aggregation.Field)
+		}
+		if names[aggregation.Name()] {
+			return nil, fmt.Errorf("aggregate %s is given more than once", aggregation.Name())
+		}
+		names[aggregation.Name()] = true
+	}
+	return &Aggregator{groupBy: groupBy, aggregations: aggregations, groups: make(map[string]*aggregateGroup)}, nil
+}
+
+func (aggregator *Aggregator) Add(item map[string]any) error {
+	values := make([]any, len(aggregator.groupBy))
+	for index, field := range aggregator.groupBy {
+		values[index], _ = FieldValue(item, field)
+	}
+	groupKey, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to Marshal group %v as json [%w]", values, err)
+	}
+	group, found := aggregator.groups[string(groupKey)]
+	if !found {
+		group = &aggregateGroup{
+			values:   values,
+			sums:     make([]float64, len(aggregator.aggregations)),
+			numbers:  make([]int, len(aggregator.aggregations)),
+			extremes: make([]any, len(aggregator.aggregations)),
+		}
+		aggregator.groups[string(groupKey)] = group
+	}
+	group.count++
+	for index, aggregation := range aggregator.aggregations {
+		value, found := FieldValue(item, aggregation.Field)
+		if !found || value == nil {
+			continue
+		}
+		switch aggregation.Function {
+		case "sum", "avg":
+			if number, ok := toFloat(value); ok {
+				group.sums[index] += number
+				group.numbers[index]++
+			}
+		case "min":
+			if group.extremes[index] == nil || CompareValues(value, group.extremes[index]) < 0 {
+				group.extremes[index] = value
+			}
+		case "max":
+			if group.extremes[index] == nil || CompareValues(value, group.extremes[index]) > 0 {
+				group.extremes[index] = value
+			}
+		}
+	}
+	return nil
+}
+
+// Results returns a record per group, ordered by the group by values, with
+// the group by fields and the aggregates. Without group by fields there is a
+// single record, also when no items were added
+func (aggregator *Aggregator) Results() []map[string]any {
+	groups := slices.Collect(maps.Values(aggregator.groups))
+	if len(groups) == 0 && len(aggregator.groupBy) == 0 {
+		groups = append(groups, &aggregateGroup{
+			sums:     make([]float64, len(aggregator.aggregations)),
+			numbers:  make([]int, len(aggregator.aggregations)),
+			extremes: make([]any, len(aggregator.aggregations)),
+		})
+	}
+	slices.SortFunc(groups, func(a, b *aggregateGroup) int {
+		for index := range a.values {
+			if comparison := CompareValues(a.values[index], b.values[index]); comparison != 0 {
+				return comparison
+			}
+		}
+		return 0
+	})
+
+	results := make([]map[string]any, 0, len(groups))
+	for _, group := range groups {
+		result := make(map[string]any)
+		for index, field := range aggregator.groupBy {
+			result[field] = group.values[index]
+		}
+		for index, aggregation := range aggregator.aggregations {
+			switch aggregation.Function {
+			case "count":
+				result[aggregation.Name()] = group.count
+			case "sum":
+				result[aggregation.Name()] = group.sums[index]
+			case "avg":
+				var average any // null without numbers
+				if group.numbers[index] > 0 {
+					average = group.sums[index] / float64(group.numbers[index])
+				}
+				result[aggregation.Name()] = average
+			case "min", "max":
+				result[aggregation.Name()] = group.extremes[index]
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// FieldValue returns the value of a field of an unmarshalled item, nested
+// fields of maps are separated by dots, e.g. address.city
+func FieldValue(item map[string]any, path string) (any, bool) {
+	if value, found := item[path]; found {
+		return value, true
+	}
+	var value any = item
+	for name := range strings.SplitSeq(path, ".") {
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// SortKey orders records by a field
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSortKey parses field, field:asc or field:desc
+func ParseSortKey(arg string) (SortKey, error) {
+	field, order, _ := strings.Cut(arg, ":")
+	if field == "" {
+		return SortKey{}, fmt.Errorf("invalid sort key %q, expected field[:desc]", arg)
+	}
+	switch strings.ToLower(order) {
+	case "", "asc":
+		return SortKey{Field: field}, nil
+	case "desc":
+		return SortKey{Field: field, Descending: true}, nil
+	}
+	return SortKey{}, fmt.Errorf("invalid sort order %q of %s, expected asc or desc", order, field)
+}
+
+// SortRecords sorts records by the keys in turn, keeping the order of equal
+// records. Missing fields sort like null
+func SortRecords(records []map[string]any, keys []SortKey) {
+	slices.SortStableFunc(records, func(a, b map[string]any) int {
+		for _, key := range keys {
+			aValue, _ := FieldValue(a, key.Field)
+			bValue, _ := FieldValue(b, key.Field)
+			comparison := CompareValues(aValue, bValue)
+			if key.Descending {
+				comparison = -comparison
+			}
+			if comparison != 0 {
+				return comparison
+			}
+		}
+		return 0
+	})
+}
+
+// CompareValues orders unmarshalled values like jq, null before booleans,
+// numbers, strings and then other values, which are compared as json
+func CompareValues(a, b any) int {
+	if rankA, rankB := valueRank(a), valueRank(b); rankA != rankB {
+		return rankA - rankB
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		if a == b {
+			return 0
+		} else if b {
+			return -1
+		}
+		return 1
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	if aNumber, ok := toFloat(a); ok {
+		bNumber, _ := toFloat(b)
+		switch {
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		}
+		return 0
+	}
+	aJson, _ := json.Marshal(a)
+	bJson, _ := json.Marshal(b)
+	return strings.Compare(string(aJson), string(bJson))
+}
+
+func valueRank(value any) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case string:
+		return 3
+	}
+	if _, ok := toFloat(value); ok {
+		return 2
+	}
+	return 4
+}
+
+func toFloat(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	aggregator, err := NewAggregator([]string{"address.city"}, []Aggregation{
+		{Function: "count"}, {Function: "avg", Field: "total"}, {Function: "min", Field: "day"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []map[string]any{
+		{"address": map[string]any{"city": "Oslo"}, "total": 5.0, "day": "2025-02-01"},
+		{"address": map[string]any{"city": "Oslo"}, "total": "unknown", "day": "2025-01-01"},
+		{"address": map[string]any{"city": "Bergen"}, "total": 2.0},
+		{"total": 1.0},
+	} {
+		if err := aggregator.Add(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := json.Marshal(aggregator.Results())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"address.city":null,"avg_total":1,"count":1,"min_day":null},` +
+		`{"address.city":"Bergen","avg_total":2,"count":1,"min_day":null},` +
+		`{"address.city":"Oslo","avg_total":5,"count":2,"min_day":"2025-01-01"}]`
+	if string(results) != want {
+		t.Errorf("got %s want %s", results, want)
+	}
+
+	empty, _ := NewAggregator(nil, []Aggregation{{Function: "count"}, {Function: "sum", Field: "total"}})
+	if results, _ := json.Marshal(empty.Results()); string(results) != `[{"count":0,"sum_total":0}]` {
+		t.Errorf("got %s for no items", results)
+	}
+	for _, test := range []struct {
+		name         string
+		groupBy      []string
+		aggregations []Aggregation
+	}{
+		{"unknown function", nil, []Aggregation{{Function: "median", Field: "total"}}},
+		{"repeated aggregation", nil, []Aggregation{{Function: "sum", Field: "total"}, {Function: "sum", Field: "total"}}},
+		{"repeated group by", []string{"status", "status"}, []Aggregation{{Function: "count"}}},
+		{"group by count", []string{"count"}, []Aggregation{{Function: "count"}}},
+		{"group by aggregate", []string{"sum_total"}, []Aggregation{{Function: "sum", Field: "total"}}},
+	} {
+		if _, err := NewAggregator(test.groupBy, test.aggregations); err == nil {
+			t.Errorf("got no error for %s", test.name)
+		}
+	}
+}
+
+func TestSortRecords(t *testing.T) {
+	records := []map[string]any{{"n": 2.0}, {"n": "b"}, {}, {"n": true}, {"n": 10.0, "m": 1.0}, {"n": 10.0, "m": 2.0}}
+	SortRecords(records, []SortKey{{Field: "n"}, {Field: "m", Descending: true}})
+	got, _ := json.Marshal(records)
+	if want := `[{},{"n":true},{"n":2},{"m":2,"n":10},{"m":1,"n":10},{"n":"b"}]`; string(got) != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+	for arg, valid := range map[string]bool{"total": true, "total:DESC": true, "total:up": false, ":desc": false} {
+		if _, err := ParseSortKey(arg); (err == nil) != valid {
+			t.Errorf("got error %v parsing %q", err, arg)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func CreateBackup(ctx context.Context, client DynamodbAPI, tableName, backupName string) (*types.BackupDetails, error) {
+	output, err := client.CreateBackup(ctx, &dynamodb.CreateBackupInput{TableName: &tableName, BackupName: &backupName})
+	if err != nil {
+		return nil, newRequestError("CreateBackup", err)
+	}
+	return output.BackupDetails, nil
+}
+
+func DescribeBackup(ctx context.Context, client DynamodbAPI, backupArn string) (*types.BackupDescription, error) {
+	output, err := client.DescribeBackup(ctx, &dynamodb.DescribeBackupInput{BackupArn: &backupArn})
+	if err != nil {
+		return nil, newRequestError("DescribeBackup", err)
+	}
+	return output.BackupDescription, nil
+}
+
+// ListBackups yields the backups of a table, or of all tables when tableName is empty
+func ListBackups(ctx context.Context, client DynamodbAPI, tableName string, backupType types.BackupTypeFilter) iter.Seq2[types.BackupSummary, error] {
+	return func(yield func(types.BackupSummary, error) bool) {
+		input := &dynamodb.ListBackupsInput{BackupType: backupType}
+		if tableName != "" {
+			input.TableName = &tableName
+		}
+		for {
+			output, err := client.ListBackups(ctx, input)
+			if err != nil {
+				yield(types.BackupSummary{}, newRequestError("ListBackups", err))
+				return
+			}
+			for _, summary := range output.BackupSummaries {
+				if !yield(summary, nil) {
+					return
+				}
+			}
+			if output.LastEvaluatedBackupArn == nil {
+				return
+			}
+			input.ExclusiveStartBackupArn = output.LastEvaluatedBackupArn
+		}
+	}
+}
+
+func DeleteBackup(ctx context.Context, client DynamodbAPI, backupArn string) error {
+	if _, err := client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{BackupArn: &backupArn}); err != nil {
+		return newRequestError("DeleteBackup", err)
+	}
+	return nil
+}
+
+func RestoreTableFromBackup(ctx context.Context, client DynamodbAPI, backupArn, tableName string) error {
+	_, err := client.RestoreTableFromBackup(ctx, &dynamodb.RestoreTableFromBackupInput{BackupArn: &backupArn, TargetTableName: &tableName})
+	if err != nil {
+		return newRequestError("RestoreTableFromBackup", err)
+	}
+	return nil
+}
+
+// RestoreTableToPointInTime restores a table as it was at the given time, or
+// at the latest restorable time when restoreTime is zero
+func RestoreTableToPointInTime(ctx context.Context, client DynamodbAPI, sourceTableName, tableName string, restoreTime time.Time) error {
+	input := &dynamodb.RestoreTableToPointInTimeInput{SourceTableName: &sourceTableName, TargetTableName: &tableName}
+	if restoreTime.IsZero() {
+		input.UseLatestRestorableTime = aws.Bool(true)
+	} else {
+		input.RestoreDateTime = &restoreTime
+	}
+	if _, err := client.RestoreTableToPointInTime(ctx, input); err != nil {
+		return newRequestError("RestoreTableToPointInTime", err)
+	}
+	return nil
+}
+
+// PointInTimeRecovery returns the point in time recovery status and restorable period of a table
+func PointInTimeRecovery(ctx context.Context, client DynamodbAPI, tableName string) (*types.PointInTimeRecoveryDescription, error) {
+	output, err := client.DescribeContinuousBackups(ctx, &dynamodb.DescribeContinuousBackupsInput{TableName: &tableName})
+	if err != nil {
+		return nil, newRequestError("DescribeContinuousBackups", err)
+	}
+	if output.ContinuousBackupsDescription == nil || output.ContinuousBackupsDescription.PointInTimeRecoveryDescription == nil {
+		return &types.PointInTimeRecoveryDescription{PointInTimeRecoveryStatus: types.PointInTimeRecoveryStatusDisabled}, nil
+	}
+	return output.ContinuousBackupsDescription.PointInTimeRecoveryDescription, nil
+}
+
+func UpdatePointInTimeRecovery(ctx context.Context, client DynamodbAPI, tableName string, enabled bool) error {
+	_, err := client.UpdateContinuousBackups(ctx, &dynamodb.UpdateContinuousBackupsInput{
+		TableName:                        &tableName,
+		PointInTimeRecoverySpecification: &types.PointInTimeRecoverySpecification{PointInTimeRecoveryEnabled: &enabled},
+	})
+	if err != nil {
+		return newRequestError("UpdateContinuousBackups", err)
+	}
+	return nil
+}
+
+// WaitForBackup polls until the backup is AVAILABLE
+func WaitForBackup(ctx context.Context, client DynamodbAPI, backupArn string) (*types.BackupDescription, error) {
+	for {
+		description, err := DescribeBackup(ctx, client, backupArn)
+		if err != nil {
+			return nil, err
+		}
+		status := description.BackupDetails.BackupStatus
+		switch status {
+		case types.BackupStatusAvailable:
+			return description, nil
+		case types.BackupStatusDeleted:
+			return nil, fmt.Errorf("backup %s was deleted", backupArn)
+		}
+		if err := sleep(ctx, WaitInterval); err != nil {
+			return nil, fmt.Errorf("stopped waiting, backup %s is %s [%w]", backupArn, status, err)
+		}
+	}
+}
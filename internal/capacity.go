@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// on-demand prices in us-east-1, in dollars per million request units
+const (
+	readUnitPrice  = 0.125
+	writeUnitPrice = 0.625
+)
+
+// CapacityUsage is the capacity consumed by a table or one of its indexes
+type CapacityUsage struct {
+	ReadUnits  float64
+	WriteUnits float64
+}
+
+// CapacityRecorder asks for the consumed capacity of every request through the
+// client it wraps and adds it up per table and index
+type CapacityRecorder struct {
+	DynamodbAPI
+	*capacityTotals
+}
+
+// capacityTotals is the usage added up by a recorder and the recorders of other
+// clients it wraps
+type capacityTotals struct {
+	mutex sync.Mutex
+	// Total includes the capacity of tables and their indexes,
+	// Usage splits it by table name, or table:index for indexes
+	Total    CapacityUsage
+	Usage    map[string]*CapacityUsage
+	Requests int
+	Scanned  int64
+	Returned int64
+}
+
+func NewCapacityRecorder(client DynamodbAPI) *CapacityRecorder {
+	return &CapacityRecorder{DynamodbAPI: client, capacityTotals: &capacityTotals{Usage: make(map[string]*CapacityUsage)}}
+}
+
+// Wrap returns a recorder of the requests of another client, e.g. to a table in
+// another region, adding them up with the requests of this one
+func (r *CapacityRecorder) Wrap(client DynamodbAPI) *CapacityRecorder {
+	return &CapacityRecorder{DynamodbAPI: client, capacityTotals: r.capacityTotals}
+}
+
+func (usage *CapacityUsage) add(write bool, capacity types.Capacity) {
+	switch {
+	case capacity.ReadCapacityUnits != nil || capacity.WriteCapacityUnits != nil:
+		if capacity.ReadCapacityUnits != nil {
+			usage.ReadUnits += *capacity.ReadCapacityUnits
+		}
+		if capacity.WriteCapacityUnits != nil {
+			usage.WriteUnits += *capacity.WriteCapacityUnits
+		}
+	case capacity.CapacityUnits != nil && write:
+		usage.WriteUnits += *capacity.CapacityUnits
+	case capacity.CapacityUnits != nil:
+		usage.ReadUnits += *capacity.CapacityUnits
+	}
+}
+
+func (r *CapacityRecorder) usage(name string) *CapacityUsage {
+	usage, found := r.Usage[name]
+	if !found {
+		usage = &CapacityUsage{}
+		r.Usage[name] = usage
+	}
+	return usage
+}
+
+// record adds the consumed capacity of one request, capacity that isn't split
+// in read and write units counts as writes when write is set
+func (r *CapacityRecorder) record(write bool, consumed ...types.ConsumedCapacity) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Requests++
+	for _, capacity := range consumed {
+		total := types.Capacity{
+			CapacityUnits:      capacity.CapacityUnits,
+			ReadCapacityUnits:  capacity.ReadCapacityUnits,
+			WriteCapacityUnits: capacity.WriteCapacityUnits,
+		}
+		r.Total.add(write, total)
+		if capacity.TableName == nil {
+			continue
+		}
+		tableName := *capacity.TableName
+		if capacity.Table != nil {
+			r.usage(tableName).add(write, *capacity.Table)
+		} else {
+			r.usage(tableName).add(write, total)
+		}
+		for indexName, indexCapacity := range capacity.GlobalSecondaryIndexes {
+			r.usage(tableName+":"+indexName).add(write, indexCapacity)
+		}
+		for indexName, indexCapacity := range capacity.LocalSecondaryIndexes {
+			r.usage(tableName+":"+indexName).add(write, indexCapacity)
+		}
+	}
+}
+
+func (r *CapacityRecorder) count(scanned, returned int32) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Scanned += int64(scanned)
+	r.Returned += int64(returned)
+}
+
+// Cost estimates the on-demand price in dollars of the recorded usage
+func (r *CapacityRecorder) Cost() float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return (r.Total.ReadUnits*readUnitPrice + r.Total.WriteUnits*writeUnitPrice) / 1e6
+}
+
+// Report writes the usage per table and index, the scanned and returned
+// item counts and the cost estimate
+func (r *CapacityRecorder) Report(writer io.Writer) {
+	cost := r.Cost()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	fmt.Fprintf(writer, "consumed %g read units and %g write units in %d requests:\n",
+		r.Total.ReadUnits, r.Total.WriteUnits, r.Requests)
+	tabWriter := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	for _, name := range slices.Sorted(maps.Keys(r.Usage)) {
+		usage := r.Usage[name]
+		fmt.Fprintf(tabWriter, "  %s\t%g read units\t%g write units\n", name, usage.ReadUnits, usage.WriteUnits)
+	}
+	tabWriter.Flush()
+	if r.Scanned > 0 {
+		fmt.Fprintf(writer, "scanned %d items, returned %d (%.1f%%)\n",
+			r.Scanned, r.Returned, float64(r.Returned)/float64(r.Scanned)*100)
+	}
+	fmt.Fprintf(writer, "estimated on-demand cost $%.6f at us-east-1 prices\n", cost)
+}
+
+func (r *CapacityRecorder) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.GetItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		r.record(false, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.PutItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		r.record(true, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.DeleteItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		r.record(true, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.Query(ctx, &input, optFns...)
+	if err == nil {
+		if output.ConsumedCapacity != nil {
+			r.record(false, *output.ConsumedCapacity)
+		}
+		r.count(output.ScannedCount, output.Count)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.Scan(ctx, &input, optFns...)
+	if err == nil {
+		if output.ConsumedCapacity != nil {
+			r.record(false, *output.ConsumedCapacity)
+		}
+		r.count(output.ScannedCount, output.Count)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.TransactWriteItems(ctx, &input, optFns...)
+	if err == nil {
+		r.record(true, output.ConsumedCapacity...)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.ExecuteStatement(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		r.record(params.Statement != nil && !isSelect(*params.Statement), *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (r *CapacityRecorder) BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	input := *params
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityIndexes
+	output, err := r.DynamodbAPI.BatchExecuteStatement(ctx, &input, optFns...)
+	if err == nil {
+		write := slices.ContainsFunc(params.Statements, func(statement types.BatchStatementRequest) bool {
+			return statement.Statement != nil && !isSelect(*statement.Statement)
+		})
+		r.record(write, output.ConsumedCapacity...)
+	}
+	return output, err
+}
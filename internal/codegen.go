@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// GoOptions configures the go code generated for a table
+type GoOptions struct {
+	Package   string
+	TableName string
+	Keys      []Key // of the table
+	Command   string
+}
+
+// goGenerator writes the structs of the entities of a schema
+type goGenerator struct {
+	schema  *Schema
+	options GoOptions
+	code    strings.Builder
+	names   map[string]bool // type names in use
+	nested  []string        // declarations of map types to write after the current entity
+}
+
+// initialisms are written in capitals in go names
+var initialisms = map[string]bool{
+	"api": true, "arn": true, "gsi": true, "http": true, "id": true, "ip": true, "json": true, "lsi": true,
+	"pk": true, "sk": true, "ttl": true, "uri": true, "url": true, "uuid": true,
+}
+
+// GenerateGo returns formatted go source with a struct per entity of the
+// schema, tagged for attributevalue, and key types with their builders
+func GenerateGo(schema *Schema, options GoOptions) ([]byte, error) {
+	generator := &goGenerator{schema: schema, options: options, names: make(map[string]bool)}
+	fmt.Fprintf(&generator.code, "// Code generated by %s; DO NOT EDIT.\n\npackage %s\n", options.Command, options.Package)
+	for _, entity := range schema.Entities {
+		generator.writeEntity(entity)
+	}
+	source, err := format.Source([]byte(generator.code.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code [%w]", err)
+	}
+	return source, nil
+}
+
+func (g *goGenerator) entityName(entity *EntitySchema) string {
+	switch {
+	case entity.Prefix != "":
+		return GoName(entity.Prefix)
+	case len(g.schema.Entities) == 1:
+		return GoName(g.options.TableName)
+	}
+	return GoName(g.options.TableName) + "Other"
+}
+
+func (g *goGenerator) writeEntity(entity *EntitySchema) {
+	name := g.uniqueName(g.entityName(entity))
+	description := fmt.Sprintf("an item of %s", g.options.TableName)
+	if entity.Prefix != "" {
+		description += fmt.Sprintf(" with %s starting with %s%s", g.schema.GroupBy, entity.Prefix, g.schema.Separator)
+	}
+	fmt.Fprintf(&g.code, "\n// %s is %s, inferred from %d items\n", name, description, entity.Items)
+
+	// the keys come first and are never optional
+	keyFields := make(map[string]string)
+	fmt.Fprintf(&g.code, "type %s struct {\n", name)
+	for _, key := range g.options.Keys {
+		fieldName := GoName(key.Name)
+		keyFields[key.Name] = fieldName
+		fmt.Fprintf(&g.code, "\t%s %s `dynamodbav:%s`\n", fieldName, g.keyType(key, entity.Attributes[key.Name]), strconv.Quote(key.Name))
+	}
+	g.writeFields(&g.code, name, entity.Items, entity.Attributes, keyFields)
+	fmt.Fprintf(&g.code, "}\n")
+	g.writeKey(name, entity, keyFields)
+
+	nested := g.nested
+	g.nested = nil
+	for _, declaration := range nested {
+		g.code.WriteString(declaration)
+	}
+}
+
+// writeFields writes a field per attribute, optional attributes are pointers or omitted when empty
+func (g *goGenerator) writeFields(code *strings.Builder, typeName string, count int, fields map[string]*AttributeSchema, skip map[string]string) {
+	used := make(map[string]bool)
+	for _, fieldName := range skip {
+		used[fieldName] = true
+	}
+	for _, attributeName := range slices.Sorted(maps.Keys(fields)) {
+		if _, found := skip[attributeName]; found {
+			continue
+		}
+		attribute := fields[attributeName]
+		fieldName := GoName(attributeName)
+		for suffix := 2; used[fieldName]; suffix++ {
+			fieldName = GoName(attributeName) + strconv.Itoa(suffix)
+		}
+		used[fieldName] = true
+
+		optional := attribute.Count < count || attribute.Types["NULL"] > 0
+		goType, option := g.goType(typeName+fieldName, attribute)
+		tag := attributeName
+		if optional {
+			if isScalarType(goType) {
+				goType = "*" + goType
+			}
+			tag += ",omitempty"
+		}
+		if option != "" {
+			tag += "," + option
+		}
+		fmt.Fprintf(code, "\t%s %s `dynamodbav:%s`\n", fieldName, goType, strconv.Quote(tag))
+	}
+}
+
+// goType returns the go type of the values of an attribute and the tag option
+// of sets, map values get a struct named typeName
+func (g *goGenerator) goType(typeName string, attribute *AttributeSchema) (string, string) {
+	typeNames := slices.DeleteFunc(attribute.TypeNames(), func(name string) bool { return name == "NULL" })
+	if len(typeNames) != 1 {
+		return "any", ""
+	}
+	number := "int64"
+	if attribute.Decimal {
+		number = "float64"
+	}
+	switch typeNames[0] {
+	case "S":
+		return "string", ""
+	case "N":
+		return number, ""
+	case "B":
+		return "[]byte", ""
+	case "BOOL":
+		return "bool", ""
+	case "SS":
+		return "[]string", "stringset"
+	case "NS":
+		return "[]" + number, "numberset"
+	case "BS":
+		return "[][]byte", "binaryset"
+	case "L":
+		if attribute.Elements == nil {
+			return "[]any", ""
+		}
+		elementType, _ := g.goType(typeName+"Item", attribute.Elements)
+		return "[]" + elementType, ""
+	case "M":
+		if len(attribute.Fields) == 0 {
+			return "map[string]any", ""
+		}
+		return g.mapStruct(typeName, attribute), ""
+	}
+	return "any", ""
+}
+
+// mapStruct declares a struct for the values of a map attribute
+func (g *goGenerator) mapStruct(typeName string, attribute *AttributeSchema) string {
+	name := g.uniqueName(typeName)
+	var code strings.Builder
+	fmt.Fprintf(&code, "\ntype %s struct {\n", name)
+	g.writeFields(&code, name, attribute.Types["M"], attribute.Fields, nil)
+	fmt.Fprintf(&code, "}\n")
+	g.nested = append(g.nested, code.String())
+	return name
+}
+
+func (g *goGenerator) keyType(key Key, attribute *AttributeSchema) string {
+	switch key.AttributeType {
+	case types.ScalarAttributeTypeN:
+		if attribute != nil && attribute.Decimal {
+			return "float64"
+		}
+		return "int64"
+	case types.ScalarAttributeTypeB:
+		return "[]byte"
+	}
+	return "string"
+}
+
+// writeKey writes the key type of an entity, a method returning the key of
+// an item and a function building a key, adding the prefix of the entity
+func (g *goGenerator) writeKey(name string, entity *EntitySchema, keyFields map[string]string) {
+	keyName := g.uniqueName(name + "Key")
+	fmt.Fprintf(&g.code, "\n// %s is the primary key of a %s\ntype %s struct {\n", keyName, name, keyName)
+	var parameters, values, copies []string
+	for _, key := range g.options.Keys {
+		fieldName := keyFields[key.Name]
+		goType := g.keyType(key, entity.Attributes[key.Name])
+		fmt.Fprintf(&g.code, "\t%s %s `dynamodbav:%s`\n", fieldName, goType, strconv.Quote(key.Name))
+
+		parameter := parameterName(fieldName)
+		parameters = append(parameters, parameter+" "+goType)
+		value := parameter
+		if entity.Prefix != "" && key.Name == g.schema.GroupBy && goType == "string" {
+			value = strconv.Quote(entity.Prefix+g.schema.Separator) + " + " + parameter
+		}
+		values = append(values, fieldName+": "+value)
+		copies = append(copies, fieldName+": item."+fieldName)
+	}
+	fmt.Fprintf(&g.code, "}\n")
+
+	method := "Key"
+	if slices.ContainsFunc(slices.Collect(maps.Keys(entity.Attributes)), func(attributeName string) bool { return GoName(attributeName) == "Key" }) {
+		method = "ItemKey"
+	}
+	fmt.Fprintf(&g.code, "\n// %s returns the primary key of the %s\nfunc (item %s) %s() %s {\n\treturn %s{%s}\n}\n",
+		method, name, name, method, keyName, keyName, strings.Join(copies, ", "))
+	builderDoc := fmt.Sprintf("New%s returns the primary key of a %s", keyName, name)
+	if entity.Prefix != "" && slices.ContainsFunc(g.options.Keys, func(key Key) bool { return key.Name == g.schema.GroupBy }) {
+		builderDoc += fmt.Sprintf(", prefixing %s with %s%s", g.schema.GroupBy, entity.Prefix, g.schema.Separator)
+	}
+	fmt.Fprintf(&g.code, "\n// %s\nfunc New%s(%s) %s {\n\treturn %s{%s}\n}\n",
+		builderDoc, keyName, strings.Join(parameters, ", "), keyName, keyName, strings.Join(values, ", "))
+}
+
+func (g *goGenerator) uniqueName(name string) string {
+	unique := name
+	for suffix := 2; g.names[unique]; suffix++ {
+		unique = name + strconv.Itoa(suffix)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+func isScalarType(goType string) bool {
+	return goType != "any" && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[")
+}
+
+// GoName converts an attribute name to an exported go identifier, e.g.
+// order_id and orderId to OrderID and ORDER to Order
+func GoName(name string) string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		// a word starts at an upper case letter after a lower case one, or
+		// before a lower case one ending a run of upper case letters
+		if len(word) > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var builder strings.Builder
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if initialisms[strings.TrimRight(lower, "0123456789")] {
+			builder.WriteString(strings.ToUpper(word))
+			continue
+		}
+		wordRunes := []rune(lower)
+		wordRunes[0] = unicode.ToUpper(wordRunes[0])
+		builder.WriteString(string(wordRunes))
+	}
+	goName := builder.String()
+	if goName == "" || !unicode.IsLetter([]rune(goName)[0]) {
+		goName = "X" + goName
+	}
+	return goName
+}
+
+// parameterName converts an exported go name to a parameter name, e.g. OrderID to orderID
+func parameterName(goName string) string {
+	runes := []rune(goName)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) && (i == 0 || i+1 == len(runes) || unicode.IsUpper(runes[i+1])) {
+		runes[i] = unicode.ToLower(runes[i])
+		i++
+	}
+	name := string(runes)
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	return name
+}
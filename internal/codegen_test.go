@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestGoName(t *testing.T) {
+	for name, want := range map[string]string{
+		"customer":  "Customer",
+		"order_id":  "OrderID",
+		"orderId":   "OrderID",
+		"ORDER":     "Order",
+		"GSI1PK":    "GSI1PK",
+		"HTTPCode":  "HTTPCode",
+		"2fa":       "X2fa",
+		"unit-cost": "UnitCost",
+	} {
+		if got := GoName(name); got != want {
+			t.Errorf("GoName(%q) got %q want %q", name, got, want)
+		}
+	}
+	for goName, want := range map[string]string{"OrderID": "orderID", "PK": "pk", "Type": "typeValue"} {
+		if got := parameterName(goName); got != want {
+			t.Errorf("parameterName(%q) got %q want %q", goName, got, want)
+		}
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	schema := NewSchema("sk", "#")
+	for _, values := range []map[string]any{
+		{"pk": "CUSTOMER#1", "sk": "ORDER#1", "total": 5.5, "tags": []string{"gift"}, "address": map[string]any{"city": "Oslo"}},
+		{"pk": "CUSTOMER#1", "sk": "ORDER#2", "total": 7, "lines": []any{map[string]any{"sku": "a", "quantity": 1}}},
+	} {
+		item, err := MarshalItem(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item["tags"] = &types.AttributeValueMemberSS{Value: []string{"gift"}}
+		schema.Add(item)
+	}
+	source, err := GenerateGo(schema, GoOptions{
+		Package:   "app",
+		TableName: "app",
+		Keys:      []Key{{Name: "pk", KeyType: types.KeyTypeHash, AttributeType: "S"}, {Name: "sk", KeyType: types.KeyTypeRange, AttributeType: "S"}},
+		Command:   "ddb codegen go app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type Order struct {",
+		"PK      string           `dynamodbav:\"pk\"`",
+		"Address *OrderAddress    `dynamodbav:\"address,omitempty\"`",
+		"Lines   []OrderLinesItem `dynamodbav:\"lines,omitempty\"`",
+		"Tags    []string         `dynamodbav:\"tags,stringset\"`",
+		"Total   float64          `dynamodbav:\"total\"`",
+		"Quantity int64  `dynamodbav:\"quantity\"`",
+		"func (item Order) Key() OrderKey {",
+		"func NewOrderKey(pk string, sk string) OrderKey {\n\treturn OrderKey{PK: pk, SK: \"ORDER#\" + sk}",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("got\n%s\nwant it to contain\n%s", source, want)
+		}
+	}
+}
@@ -1,17 +1,52 @@
 package internal
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"iter"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
-var client *dynamodb.Client
+// DynamodbAPI is the part of the dynamodb client used by ddb, implemented by
+// *dynamodb.Client and by FakeDynamodb for tests
+type DynamodbAPI interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+	DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+	DescribeContinuousBackups(ctx context.Context, params *dynamodb.DescribeContinuousBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeContinuousBackupsOutput, error)
+	UpdateContinuousBackups(ctx context.Context, params *dynamodb.UpdateContinuousBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateContinuousBackupsOutput, error)
+	CreateBackup(ctx context.Context, params *dynamodb.CreateBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateBackupOutput, error)
+	DescribeBackup(ctx context.Context, params *dynamodb.DescribeBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeBackupOutput, error)
+	ListBackups(ctx context.Context, params *dynamodb.ListBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListBackupsOutput, error)
+	DeleteBackup(ctx context.Context, params *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error)
+	RestoreTableFromBackup(ctx context.Context, params *dynamodb.RestoreTableFromBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.RestoreTableFromBackupOutput, error)
+	RestoreTableToPointInTime(ctx context.Context, params *dynamodb.RestoreTableToPointInTimeInput, optFns ...func(*dynamodb.Options)) (*dynamodb.RestoreTableToPointInTimeOutput, error)
+	ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error)
+	BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error)
+}
 
 type Attributes map[string]types.AttributeDefinition
 
@@ -23,75 +58,135 @@ type Key struct {
 
 type Item map[string]types.AttributeValue
 
-func DynamodbClient() (*dynamodb.Client, error) {
-	if client == nil {
-		config, err := config.LoadDefaultConfig(context.TODO())
-		if err != nil {
-			return nil, fmt.Errorf("failed to load aws config")
+// ClientOptions override the default aws config, empty values are ignored
+type ClientOptions struct {
+	EndpointUrl string
+	Region      string
+	Profile     string
+	RoleArn     string
+	// RequestTimeout limits each http request, including retries individually
+	RequestTimeout time.Duration
+	// RetryMode, MaxAttempts and MaxBackoff replace the sdk retry defaults
+	RetryMode   aws.RetryMode
+	MaxAttempts int
+	MaxBackoff  time.Duration
+}
+
+func AwsConfig(ctx context.Context, options ClientOptions) (aws.Config, error) {
+	var loadOptions []func(*config.LoadOptions) error
+	if options.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(options.Region))
+	}
+	if options.Profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(options.Profile))
+	}
+	if options.RequestTimeout > 0 {
+		loadOptions = append(loadOptions, config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(options.RequestTimeout)))
+	}
+	if options.RetryMode != "" || options.MaxAttempts > 0 || options.MaxBackoff > 0 {
+		loadOptions = append(loadOptions, config.WithRetryer(func() aws.Retryer {
+			return newRetryer(options)
+		}))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load aws config [%w]", err)
+	}
+	if options.RoleArn != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsConfig), options.RoleArn)
+		awsConfig.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return awsConfig, nil
+}
+
+func newRetryer(options ClientOptions) aws.Retryer {
+	standardOptions := func(o *retry.StandardOptions) {
+		if options.MaxAttempts > 0 {
+			o.MaxAttempts = options.MaxAttempts
 		}
-		client = dynamodb.NewFromConfig(config)
+		if options.MaxBackoff > 0 {
+			o.MaxBackoff = options.MaxBackoff
+		}
+	}
+	if options.RetryMode == aws.RetryModeAdaptive {
+		return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+			o.StandardOptions = append(o.StandardOptions, standardOptions)
+		})
 	}
-	return client, nil
+	return retry.NewStandard(standardOptions)
 }
 
-func GetTableKeys(client *dynamodb.Client, table string) ([]Key, error) {
-	tableDescription, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+func DynamodbClient(ctx context.Context, options ClientOptions) (*dynamodb.Client, error) {
+	config, err := AwsConfig(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(config, func(o *dynamodb.Options) {
+		if options.EndpointUrl != "" {
+			o.BaseEndpoint = aws.String(options.EndpointUrl)
+		}
+	}), nil
+}
+
+func DescribeTable(ctx context.Context, client DynamodbAPI, table string) (*types.TableDescription, error) {
+	tableDescription, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
 		TableName: &table,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe table [%w]", err)
+		return nil, newRequestError("DescribeTable", err)
 	}
+	return tableDescription.Table, nil
+}
+
+// SchemaKeys returns the keys of a key schema with their types from the attribute definitions
+func SchemaKeys(keySchema []types.KeySchemaElement, definitions []types.AttributeDefinition) []Key {
 	attributes := make(Attributes)
-	for _, attribute := range tableDescription.Table.AttributeDefinitions {
+	for _, attribute := range definitions {
 		attributes[*attribute.AttributeName] = attribute
 	}
 	var keys []Key
-	keySchema := tableDescription.Table.KeySchema
 	for _, key := range keySchema {
 		key_name := *key.AttributeName
 		keys = append(keys, Key{key_name, key.KeyType, attributes[key_name].AttributeType})
 	}
-	return keys, nil
+	return keys
 }
 
-func GetIndexKeys(client *dynamodb.Client, table string, index string) ([]Key, error) {
-	tableDescription, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
-		TableName: &table,
-	})
+func GetTableKeys(ctx context.Context, client DynamodbAPI, table string) ([]Key, error) {
+	tableDescription, err := DescribeTable(ctx, client, table)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe table [%w]", err)
+		return nil, err
 	}
-	attributes := make(Attributes)
-	for _, attribute := range tableDescription.Table.AttributeDefinitions {
-		attributes[*attribute.AttributeName] = attribute
+	return SchemaKeys(tableDescription.KeySchema, tableDescription.AttributeDefinitions), nil
+}
+
+func GetIndexKeys(ctx context.Context, client DynamodbAPI, table string, index string) ([]Key, error) {
+	tableDescription, err := DescribeTable(ctx, client, table)
+	if err != nil {
+		return nil, err
 	}
 	allIndexes := make(map[string][]types.KeySchemaElement)
-	for _, indexDefinition := range tableDescription.Table.GlobalSecondaryIndexes {
+	for _, indexDefinition := range tableDescription.GlobalSecondaryIndexes {
 		allIndexes[*indexDefinition.IndexName] = indexDefinition.KeySchema
 	}
-	for _, indexDefinition := range tableDescription.Table.LocalSecondaryIndexes {
+	for _, indexDefinition := range tableDescription.LocalSecondaryIndexes {
 		allIndexes[*indexDefinition.IndexName] = indexDefinition.KeySchema
 	}
 	keySchema, indexExists := allIndexes[index]
 	if !indexExists {
 		return nil, fmt.Errorf("table: %s doesn't have an index: %s", table, index)
 	}
-	var keys []Key
-	for _, key := range keySchema {
-		key_name := *key.AttributeName
-		keys = append(keys, Key{key_name, key.KeyType, attributes[key_name].AttributeType})
-	}
-	return keys, nil
+	return SchemaKeys(keySchema, tableDescription.AttributeDefinitions), nil
 }
 
-func GetItem(client *dynamodb.Client, tableName string, keyValues Item) (map[string]any, error) {
+func GetItem(ctx context.Context, client DynamodbAPI, tableName string, keyValues Item) (map[string]any, error) {
 
-	getOutput, err := client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	getOutput, err := client.GetItem(ctx, &dynamodb.GetItemInput{
 		Key:       keyValues,
 		TableName: &tableName,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("dynamodb.GetItem failed [%w]", err)
+		return nil, newRequestError("GetItem", err)
 	}
 
 	item, err := UnmarshalItem(getOutput.Item)
@@ -102,6 +197,67 @@ func GetItem(client *dynamodb.Client, tableName string, keyValues Item) (map[str
 	return item, nil
 }
 
+func PutItem(ctx context.Context, client DynamodbAPI, tableName string, item Item) error {
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: &tableName,
+	})
+	if err != nil {
+		return newRequestError("PutItem", err)
+	}
+	return nil
+}
+
+func DeleteItem(ctx context.Context, client DynamodbAPI, tableName string, keyValues Item) error {
+	_, err := client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		Key:       keyValues,
+		TableName: &tableName,
+	})
+	if err != nil {
+		return newRequestError("DeleteItem", err)
+	}
+	return nil
+}
+
+// maximum number of operations dynamodb accepts in one TransactWriteItems
+const TransactWriteLimit = 100
+
+func TransactWriteItems(ctx context.Context, client DynamodbAPI, transactItems []types.TransactWriteItem) error {
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		return newRequestError("TransactWriteItems", err)
+	}
+	return nil
+}
+
+func ListTables(ctx context.Context, client DynamodbAPI) ([]string, error) {
+	var tableNames []string
+	paginator := dynamodb.NewListTablesPaginator(client, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, newRequestError("ListTables", err)
+		}
+		tableNames = append(tableNames, page.TableNames...)
+	}
+	return tableNames, nil
+}
+
+// KeyValues picks the attributes of the given keys out of an item
+func KeyValues(keys []Key, item Item) (Item, error) {
+	keyValues := make(Item)
+	for _, key := range keys {
+		value, found := item[key.Name]
+		if !found {
+			return nil, fmt.Errorf("item is missing key attribute %s", key.Name)
+		}
+		keyValues[key.Name] = value
+	}
+	return keyValues, nil
+}
+
 func MarshalArgument(argumentValue string, attributeType types.ScalarAttributeType) (types.AttributeValue, error) {
 	var value any
 	switch attributeType {
@@ -117,6 +273,14 @@ func MarshalArgument(argumentValue string, attributeType types.ScalarAttributeTy
 	return attributeValue, nil
 }
 
+func MarshalItem(item map[string]any) (Item, error) {
+	dynamodbItem, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal Item [%w]", err)
+	}
+	return dynamodbItem, nil
+}
+
 func UnmarshalItem(dynamodbItem Item) (map[string]any, error) {
 	item := make(map[string]any)
 	err := attributevalue.UnmarshalMap(dynamodbItem, &item)
@@ -126,57 +290,280 @@ func UnmarshalItem(dynamodbItem Item) (map[string]any, error) {
 	return item, nil
 }
 
-func IterateQuery(client *dynamodb.Client, queryInput dynamodb.QueryInput) iter.Seq2[Item, error] {
+// WireItem converts an item to the dynamodb json format of the api, e.g. {"id":{"S":"a"}}
+func WireItem(item Item) map[string]any {
+	wire := make(map[string]any, len(item))
+	for name, value := range item {
+		wire[name] = WireValue(value)
+	}
+	return wire
+}
+
+// WireValue converts a value to the dynamodb json format of the api, e.g. {"N":"1"}
+func WireValue(value types.AttributeValue) any {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": value.Value}
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": value.Value}
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": value.Value} // encoded as base64 like the api
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": value.Value}
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": value.Value}
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": value.Value}
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": value.Value}
+	case *types.AttributeValueMemberBS:
+		return map[string]any{"BS": value.Value}
+	case *types.AttributeValueMemberL:
+		list := make([]any, 0, len(value.Value))
+		for _, element := range value.Value {
+			list = append(list, WireValue(element))
+		}
+		return map[string]any{"L": list}
+	case *types.AttributeValueMemberM:
+		return map[string]any{"M": WireItem(value.Value)}
+	}
+	return nil
+}
+
+// ParseWireItem converts an item in the dynamodb json format of the api, as
+// written by WireItem, back into an Item keeping the types of the attributes
+func ParseWireItem(data []byte) (Item, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var wire map[string]any
+	if err := decoder.Decode(&wire); err != nil {
+		return nil, fmt.Errorf("failed to decode dynamodb json item [%w]", err)
+	}
+	return parseWireMap(wire, "")
+}
+
+func parseWireMap(wire map[string]any, path string) (Item, error) {
+	item := make(Item, len(wire))
+	for name, value := range wire {
+		attribute, err := parseWireValue(value, path+name)
+		if err != nil {
+			return nil, err
+		}
+		item[name] = attribute
+	}
+	return item, nil
+}
+
+// parseWireValue converts a value like {"N":"1"}, path locates it in errors
+func parseWireValue(wire any, path string) (types.AttributeValue, error) {
+	typed, ok := wire.(map[string]any)
+	if !ok || len(typed) != 1 {
+		return nil, fmt.Errorf("invalid dynamodb json at %s, expected an object with one type like {\"S\": \"text\"}", path)
+	}
+	var typeName string
+	var value any
+	for typeName, value = range typed {
+		// the only entry
+	}
+	invalid := fmt.Errorf("invalid dynamodb json at %s, unexpected value %v of type %s", path, value, typeName)
+	switch typeName {
+	case "S", "N":
+		text, ok := value.(string)
+		if number, isNumber := value.(json.Number); isNumber && typeName == "N" {
+			text, ok = number.String(), true
+		}
+		if !ok {
+			return nil, invalid
+		}
+		if typeName == "N" {
+			return &types.AttributeValueMemberN{Value: text}, nil
+		}
+		return &types.AttributeValueMemberS{Value: text}, nil
+	case "B":
+		data, err := wireBinary(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return &types.AttributeValueMemberB{Value: data}, nil
+	case "BOOL", "NULL":
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, invalid
+		}
+		if typeName == "NULL" {
+			return &types.AttributeValueMemberNULL{Value: flag}, nil
+		}
+		return &types.AttributeValueMemberBOOL{Value: flag}, nil
+	case "SS", "NS", "BS":
+		elements, ok := value.([]any)
+		if !ok {
+			return nil, invalid
+		}
+		var texts []string
+		var binaries [][]byte
+		for _, element := range elements {
+			if typeName == "BS" {
+				data, err := wireBinary(element)
+				if err != nil {
+					return nil, invalid
+				}
+				binaries = append(binaries, data)
+				continue
+			}
+			text, ok := element.(string)
+			if number, isNumber := element.(json.Number); isNumber && typeName == "NS" {
+				text, ok = number.String(), true
+			}
+			if !ok {
+				return nil, invalid
+			}
+			texts = append(texts, text)
+		}
+		switch typeName {
+		case "SS":
+			return &types.AttributeValueMemberSS{Value: texts}, nil
+		case "NS":
+			return &types.AttributeValueMemberNS{Value: texts}, nil
+		}
+		return &types.AttributeValueMemberBS{Value: binaries}, nil
+	case "L":
+		elements, ok := value.([]any)
+		if !ok {
+			return nil, invalid
+		}
+		list := make([]types.AttributeValue, 0, len(elements))
+		for index, element := range elements {
+			attribute, err := parseWireValue(element, fmt.Sprintf("%s[%d]", path, index))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, attribute)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case "M":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, invalid
+		}
+		item, err := parseWireMap(fields, path+".")
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: item}, nil
+	}
+	return nil, fmt.Errorf("invalid dynamodb json at %s, unknown type %s", path, typeName)
+}
+
+// wireBinary decodes a binary value, base64 encoded like the api
+func wireBinary(value any) ([]byte, error) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 text")
+	}
+	return base64.StdEncoding.DecodeString(text)
+}
+
+// IterateQuery yields the items of every page of the query. A failed page ends
+// the iteration with a *RequestError holding the key to resume from.
+func IterateQuery(ctx context.Context, client DynamodbAPI, queryInput dynamodb.QueryInput) iter.Seq2[Item, error] {
 	return func(yield func(Item, error) bool) {
-		paginator := dynamodb.NewQueryPaginator(client, &queryInput)
-		for paginator.HasMorePages() {
-			page, err := paginator.NextPage(context.TODO())
+		for {
+			page, err := client.Query(ctx, &queryInput)
 			if err != nil {
-				if !yield(nil, fmt.Errorf("failed to retrive page from query paginator [%w]", err)) {
-					return
-				}
+				requestError := newRequestError("Query", err)
+				requestError.StartKey = queryInput.ExclusiveStartKey
+				yield(nil, requestError)
+				return
 			}
 			for _, item := range page.Items {
 				if !yield(item, nil) {
 					return
 				}
 			}
+			if len(page.LastEvaluatedKey) == 0 {
+				return
+			}
+			queryInput.ExclusiveStartKey = page.LastEvaluatedKey
 		}
 	}
 }
 
-func IterateScan(client *dynamodb.Client, scanInput dynamodb.ScanInput) iter.Seq2[Item, error] {
+// IterateScan yields the items of every page of the scan. A failed page ends
+// the iteration with a *RequestError holding the key to resume from.
+func IterateScan(ctx context.Context, client DynamodbAPI, scanInput dynamodb.ScanInput) iter.Seq2[Item, error] {
 	return func(yield func(Item, error) bool) {
-		paginator := dynamodb.NewScanPaginator(client, &scanInput)
-		for paginator.HasMorePages() {
-			page, err := paginator.NextPage(context.TODO())
+		for {
+			page, err := client.Scan(ctx, &scanInput)
 			if err != nil {
-				if !yield(nil, fmt.Errorf("failed to retrive page from scan paginator [%w]", err)) {
+				requestError := newRequestError("Scan", err)
+				requestError.StartKey = scanInput.ExclusiveStartKey
+				yield(nil, requestError)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
 					return
 				}
 			}
+			if len(page.LastEvaluatedKey) == 0 {
+				return
+			}
+			scanInput.ExclusiveStartKey = page.LastEvaluatedKey
+		}
+	}
+}
+
+func IterateStatement(ctx context.Context, client DynamodbAPI, statementInput dynamodb.ExecuteStatementInput) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for {
+			page, err := client.ExecuteStatement(ctx, &statementInput)
+			if err != nil {
+				yield(nil, newRequestError("ExecuteStatement", err))
+				return
+			}
 			for _, item := range page.Items {
 				if !yield(item, nil) {
 					return
 				}
 			}
+			if page.NextToken == nil {
+				return
+			}
+			statementInput.NextToken = page.NextToken
 		}
 	}
 }
 
+// BatchStatementLimit is the maximum number of statements dynamodb accepts in one BatchExecuteStatement
+const BatchStatementLimit = 25
+
+// BatchExecuteStatement runs the statements in a single request, so either
+// every statement is attempted or none is
+func BatchExecuteStatement(ctx context.Context, client DynamodbAPI, statements []types.BatchStatementRequest) ([]types.BatchStatementResponse, error) {
+	if len(statements) > BatchStatementLimit {
+		return nil, fmt.Errorf("a batch holds at most %d statements, %d were provided", BatchStatementLimit, len(statements))
+	}
+	output, err := client.BatchExecuteStatement(ctx, &dynamodb.BatchExecuteStatementInput{
+		Statements: statements,
+	})
+	if err != nil {
+		return nil, newRequestError("BatchExecuteStatement", err)
+	}
+	return output.Responses, nil
+}
+
+// UnmarshalItems converts items to plain values, stopping at the first error
 func UnmarshalItems(items iter.Seq2[Item, error]) iter.Seq2[map[string]any, error] {
 	return func(yield func(map[string]any, error) bool) {
 		for item, err := range items {
 			if err != nil {
-				if !yield(nil, err) {
-					return
-				}
+				yield(nil, err)
+				return
 			}
 			unmarshalledItem, err := UnmarshalItem(item)
 			if err != nil {
-				if !yield(nil, fmt.Errorf("failed to Unmarshal Item [%w]", err)) {
-					return
-				}
+				yield(nil, fmt.Errorf("failed to Unmarshal Item [%w]", err))
+				return
 			}
 			if !yield(unmarshalledItem, nil) {
 				return
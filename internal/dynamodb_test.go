@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// scriptedPage is a page or the error a scriptedClient returns for one request
+type scriptedPage struct {
+	items []string
+	err   error
+}
+
+// scriptedClient pages through scripted results, each page but the last
+// returns the request number as its LastEvaluatedKey
+type scriptedClient struct {
+	DynamodbAPI
+	pages     []scriptedPage
+	startKeys []Item
+}
+
+func (c *scriptedClient) page(startKey Item) ([]map[string]types.AttributeValue, Item, error) {
+	request := len(c.startKeys)
+	c.startKeys = append(c.startKeys, startKey)
+	if request >= len(c.pages) {
+		panic("request after the last page")
+	}
+	page := c.pages[request]
+	if page.err != nil {
+		return nil, nil, page.err
+	}
+	var items []map[string]types.AttributeValue
+	for _, id := range page.items {
+		items = append(items, Item{"id": &types.AttributeValueMemberS{Value: id}})
+	}
+	var lastKey Item
+	if request < len(c.pages)-1 {
+		lastKey = Item{"id": &types.AttributeValueMemberS{Value: page.items[len(page.items)-1]}}
+	}
+	return items, lastKey, nil
+}
+
+func (c *scriptedClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	items, lastKey, err := c.page(params.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: lastKey}, nil
+}
+
+func (c *scriptedClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	items, lastKey, err := c.page(params.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: lastKey}, nil
+}
+
+func apiError(code string) error {
+	return &smithy.GenericAPIError{Code: code, Message: code}
+}
+
+func TestIterateErrors(t *testing.T) {
+	var tests = []struct {
+		name      string
+		err       error
+		kind      error
+		retryable bool
+	}{
+		{"throttled", apiError("ProvisionedThroughputExceededException"), ErrThrottled, true},
+		{"throttling", apiError("ThrottlingException"), ErrThrottled, true},
+		{"validation", apiError("ValidationException"), ErrValidation, false},
+		{"missingTable", &types.ResourceNotFoundException{Message: new(string)}, ErrTableNotFound, false},
+		{"accessDenied", apiError("AccessDeniedException"), ErrAccessDenied, false},
+		{"unclassified", errors.New("connection reset"), nil, false},
+	}
+	iterators := map[string]func(DynamodbAPI) func(func(Item, error) bool){
+		"query": func(client DynamodbAPI) func(func(Item, error) bool) {
+			return IterateQuery(context.TODO(), client, dynamodb.QueryInput{})
+		},
+		"scan": func(client DynamodbAPI) func(func(Item, error) bool) {
+			return IterateScan(context.TODO(), client, dynamodb.ScanInput{})
+		},
+	}
+	for iteratorName, iterate := range iterators {
+		for _, test := range tests {
+			t.Run(iteratorName+"/"+test.name, func(t *testing.T) {
+				client := &scriptedClient{pages: []scriptedPage{
+					{items: []string{"a", "b"}},
+					{err: test.err},
+					{items: []string{"c"}},
+				}}
+				var ids []string
+				var errs []error
+				// keep going after errors, the iterator must still stop
+				for item, err := range iterate(client) {
+					if err != nil {
+						errs = append(errs, err)
+						continue
+					}
+					ids = append(ids, item["id"].(*types.AttributeValueMemberS).Value)
+				}
+				if len(ids) != 2 || len(errs) != 1 || len(client.startKeys) != 2 {
+					t.Fatalf("got items %v and errors %v after %d requests", ids, errs, len(client.startKeys))
+				}
+				err := errs[0]
+				if test.kind != nil && !errors.Is(err, test.kind) {
+					t.Errorf("got %v, want kind %v", err, test.kind)
+				}
+				if !errors.Is(err, test.err) {
+					t.Errorf("got %v, want it to wrap %v", err, test.err)
+				}
+				if IsRetryable(err) != test.retryable {
+					t.Errorf("got retryable %v", IsRetryable(err))
+				}
+				var requestError *RequestError
+				if !errors.As(err, &requestError) {
+					t.Fatalf("got %T, want a *RequestError", err)
+				}
+				if startKey := requestError.StartKey["id"].(*types.AttributeValueMemberS).Value; startKey != "b" {
+					t.Errorf("got start key %s, want b", startKey)
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshalItemsStopsAtError(t *testing.T) {
+	client := &scriptedClient{pages: []scriptedPage{
+		{items: []string{"a"}},
+		{err: apiError("ValidationException")},
+	}}
+	count := 0
+	for item, err := range UnmarshalItems(IterateScan(context.TODO(), client, dynamodb.ScanInput{})) {
+		count++
+		if err == nil && item == nil {
+			t.Errorf("got a nil item without an error")
+		}
+	}
+	if count != 2 {
+		t.Errorf("got %d results, want an item and an error", count)
+	}
+}
+
+func TestParseWireItem(t *testing.T) {
+	item := Item{
+		"id":     &types.AttributeValueMemberN{Value: "12345678901234567890123"},
+		"tags":   &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"scores": &types.AttributeValueMemberNS{Value: []string{"1.5", "2"}},
+		"data":   &types.AttributeValueMemberB{Value: []byte{0, 1, 2}},
+		"files":  &types.AttributeValueMemberBS{Value: [][]byte{{3}, {4}}},
+		"nested": &types.AttributeValueMemberM{Value: Item{
+			"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberBOOL{Value: true}, &types.AttributeValueMemberNULL{Value: true},
+			}},
+		}},
+	}
+	wireJson, err := json.Marshal(WireItem(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseWireItem(wireJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, item) {
+		t.Errorf("got %s want the item it was written from", wireJson)
+	}
+
+	for _, invalid := range []string{`{"a": "text"}`, `{"a": {"S": "x", "N": "1"}}`, `{"a": {"X": 1}}`, `{"a": {"SS": [1]}}`, `{"a": {"B": "!"}}`} {
+		if _, err := ParseWireItem([]byte(invalid)); err == nil {
+			t.Errorf("got no error parsing %s", invalid)
+		}
+	}
+}
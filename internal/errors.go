@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/smithy-go"
+)
+
+// Kinds of failed requests, match them with errors.Is
+var (
+	ErrThrottled     = errors.New("request throttled")
+	ErrValidation    = errors.New("invalid request")
+	ErrTableNotFound = errors.New("table or index not found")
+	ErrAccessDenied  = errors.New("access denied")
+)
+
+// error codes of the dynamodb api for each kind of failure
+var errorCodes = map[string]error{
+	"ThrottlingException":                    ErrThrottled,
+	"ProvisionedThroughputExceededException": ErrThrottled,
+	"RequestLimitExceeded":                   ErrThrottled,
+	"ValidationException":                    ErrValidation,
+	"SerializationException":                 ErrValidation,
+	"ResourceNotFoundException":              ErrTableNotFound,
+	"AccessDeniedException":                  ErrAccessDenied,
+	"UnrecognizedClientException":            ErrAccessDenied,
+	"MissingAuthenticationTokenException":    ErrAccessDenied,
+}
+
+// RequestError is a failed dynamodb request. Kind is one of the Err variables,
+// or nil when the failure isn't classified.
+type RequestError struct {
+	Operation string
+	Kind      error
+	// StartKey is the ExclusiveStartKey of the failed page of a query or scan,
+	// resuming from it continues where the items stopped
+	StartKey Item
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("dynamodb.%s failed [%v]", e.Operation, e.Err)
+}
+
+func (e *RequestError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable reports whether repeating the request later may succeed
+func (e *RequestError) Retryable() bool {
+	return e.Kind == ErrThrottled
+}
+
+// IsRetryable reports whether err is a failed request worth repeating later
+func IsRetryable(err error) bool {
+	var requestError *RequestError
+	return errors.As(err, &requestError) && requestError.Retryable()
+}
+
+func newRequestError(operation string, err error) *RequestError {
+	requestError := &RequestError{Operation: operation, Err: err}
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		requestError.Kind = errorCodes[apiError.ErrorCode()]
+	}
+	return requestError
+}
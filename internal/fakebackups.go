@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeBackup is a copy of a table, backups are AVAILABLE as soon as they are created
+type fakeBackup struct {
+	description types.BackupDescription
+	table       types.TableDescription
+	items       map[string]Item
+}
+
+func (f *FakeDynamodb) backup(backupArn *string) (*fakeBackup, error) {
+	backup, found := f.backups[aws.ToString(backupArn)]
+	if !found {
+		return nil, &types.BackupNotFoundException{Message: aws.String(fmt.Sprintf("Backup not found: %s", aws.ToString(backupArn)))}
+	}
+	return backup, nil
+}
+
+// snapshot copies the description and items of a table
+func (table *fakeTable) snapshot() (types.TableDescription, map[string]Item) {
+	items := make(map[string]Item, len(table.items))
+	for key, item := range table.items {
+		items[key] = copyItem(item)
+	}
+	return table.description, items
+}
+
+// restore creates a table with the schema and items of a snapshot, like
+// dynamodb it doesn't restore the stream, ttl or point in time recovery settings
+func (f *FakeDynamodb) restore(description types.TableDescription, items map[string]Item, tableName *string) (*types.TableDescription, error) {
+	if _, found := f.tables[aws.ToString(tableName)]; found {
+		return nil, &types.TableAlreadyExistsException{Message: aws.String(fmt.Sprintf("Table already exists: %s", aws.ToString(tableName)))}
+	}
+	description.TableName = tableName
+	description.TableArn = aws.String("arn:aws:dynamodb:local:000000000000:table/" + *tableName)
+	description.CreationDateTime = aws.Time(time.Now())
+	description.StreamSpecification = nil
+	description.LatestStreamArn = nil
+	description.LatestStreamLabel = nil
+	description.GlobalSecondaryIndexes = slices.Clone(description.GlobalSecondaryIndexes)
+	for i, index := range description.GlobalSecondaryIndexes {
+		description.GlobalSecondaryIndexes[i].IndexArn = aws.String(*description.TableArn + "/index/" + aws.ToString(index.IndexName))
+	}
+	description.LocalSecondaryIndexes = slices.Clone(description.LocalSecondaryIndexes)
+	for i, index := range description.LocalSecondaryIndexes {
+		description.LocalSecondaryIndexes[i].IndexArn = aws.String(*description.TableArn + "/index/" + aws.ToString(index.IndexName))
+	}
+	description.RestoreSummary = &types.RestoreSummary{RestoreDateTime: aws.Time(time.Now()), RestoreInProgress: aws.Bool(false)}
+	f.tables[*tableName] = &fakeTable{description: description, items: items}
+	return &description, nil
+}
+
+func (f *FakeDynamodb) CreateBackup(ctx context.Context, params *dynamodb.CreateBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateBackupOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToString(params.BackupName) == "" {
+		return nil, fakeValidationError("BackupName is required")
+	}
+	now := time.Now()
+	description, items := table.snapshot()
+	details := types.BackupDetails{
+		BackupArn:              aws.String(fmt.Sprintf("%s/backup/%d", *description.TableArn, now.UnixNano())),
+		BackupName:             params.BackupName,
+		BackupStatus:           types.BackupStatusAvailable,
+		BackupType:             types.BackupTypeUser,
+		BackupCreationDateTime: &now,
+		BackupSizeBytes:        aws.Int64(0),
+	}
+	f.backups[*details.BackupArn] = &fakeBackup{
+		description: types.BackupDescription{
+			BackupDetails: &details,
+			SourceTableDetails: &types.SourceTableDetails{
+				TableName:             description.TableName,
+				TableArn:              description.TableArn,
+				TableId:               aws.String(*description.TableName),
+				KeySchema:             description.KeySchema,
+				TableCreationDateTime: description.CreationDateTime,
+				ItemCount:             aws.Int64(int64(len(items))),
+				BillingMode:           description.BillingModeSummary.BillingMode,
+			},
+		},
+		table: description,
+		items: items,
+	}
+	return &dynamodb.CreateBackupOutput{BackupDetails: &details}, nil
+}
+
+func (f *FakeDynamodb) DescribeBackup(ctx context.Context, params *dynamodb.DescribeBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeBackupOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	backup, err := f.backup(params.BackupArn)
+	if err != nil {
+		return nil, err
+	}
+	description := backup.description
+	return &dynamodb.DescribeBackupOutput{BackupDescription: &description}, nil
+}
+
+func (f *FakeDynamodb) ListBackups(ctx context.Context, params *dynamodb.ListBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListBackupsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var summaries []types.BackupSummary
+	for _, backup := range f.backups {
+		details, source := backup.description.BackupDetails, backup.description.SourceTableDetails
+		if params.TableName != nil && *params.TableName != *source.TableName {
+			continue
+		}
+		if *details.BackupArn <= aws.ToString(params.ExclusiveStartBackupArn) {
+			continue
+		}
+		summaries = append(summaries, types.BackupSummary{
+			BackupArn:              details.BackupArn,
+			BackupName:             details.BackupName,
+			BackupStatus:           details.BackupStatus,
+			BackupType:             details.BackupType,
+			BackupCreationDateTime: details.BackupCreationDateTime,
+			BackupSizeBytes:        details.BackupSizeBytes,
+			TableName:              source.TableName,
+			TableArn:               source.TableArn,
+			TableId:                source.TableId,
+		})
+	}
+	slices.SortFunc(summaries, func(a, b types.BackupSummary) int {
+		return strings.Compare(*a.BackupArn, *b.BackupArn)
+	})
+	output := &dynamodb.ListBackupsOutput{}
+	if limit := int(aws.ToInt32(params.Limit)); limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+		output.LastEvaluatedBackupArn = summaries[limit-1].BackupArn
+	}
+	output.BackupSummaries = summaries
+	return output, nil
+}
+
+func (f *FakeDynamodb) DeleteBackup(ctx context.Context, params *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	backup, err := f.backup(params.BackupArn)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.backups, *params.BackupArn)
+	description := backup.description
+	details := *description.BackupDetails
+	details.BackupStatus = types.BackupStatusDeleted
+	description.BackupDetails = &details
+	return &dynamodb.DeleteBackupOutput{BackupDescription: &description}, nil
+}
+
+func (f *FakeDynamodb) RestoreTableFromBackup(ctx context.Context, params *dynamodb.RestoreTableFromBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.RestoreTableFromBackupOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	backup, err := f.backup(params.BackupArn)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]Item, len(backup.items))
+	for key, item := range backup.items {
+		items[key] = copyItem(item)
+	}
+	description, err := f.restore(backup.table, items, params.TargetTableName)
+	if err != nil {
+		return nil, err
+	}
+	description.RestoreSummary.SourceBackupArn = params.BackupArn
+	return &dynamodb.RestoreTableFromBackupOutput{TableDescription: description}, nil
+}
+
+// RestoreTableToPointInTime restores the current items, the fake keeps no history
+func (f *FakeDynamodb) RestoreTableToPointInTime(ctx context.Context, params *dynamodb.RestoreTableToPointInTimeInput, optFns ...func(*dynamodb.Options)) (*dynamodb.RestoreTableToPointInTimeOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.SourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	if table.pointInTimeRecovery.IsZero() {
+		return nil, &types.PointInTimeRecoveryUnavailableException{Message: aws.String(fmt.Sprintf("Point in time recovery is not enabled for table '%s'", *params.SourceTableName))}
+	}
+	if !aws.ToBool(params.UseLatestRestorableTime) {
+		restoreTime := aws.ToTime(params.RestoreDateTime)
+		if restoreTime.Before(table.pointInTimeRecovery) || restoreTime.After(time.Now()) {
+			return nil, &types.InvalidRestoreTimeException{Message: aws.String("Restore time is out of the restorable period")}
+		}
+	}
+	description, items := table.snapshot()
+	restored, err := f.restore(description, items, params.TargetTableName)
+	if err != nil {
+		return nil, err
+	}
+	restored.RestoreSummary.SourceTableArn = description.TableArn
+	return &dynamodb.RestoreTableToPointInTimeOutput{TableDescription: restored}, nil
+}
+
+func (f *FakeDynamodb) DescribeContinuousBackups(ctx context.Context, params *dynamodb.DescribeContinuousBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeContinuousBackupsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeContinuousBackupsOutput{ContinuousBackupsDescription: table.continuousBackups()}, nil
+}
+
+func (f *FakeDynamodb) UpdateContinuousBackups(ctx context.Context, params *dynamodb.UpdateContinuousBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateContinuousBackupsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if params.PointInTimeRecoverySpecification == nil {
+		return nil, fakeValidationError("PointInTimeRecoverySpecification is required")
+	}
+	enabled := aws.ToBool(params.PointInTimeRecoverySpecification.PointInTimeRecoveryEnabled)
+	if !enabled {
+		table.pointInTimeRecovery = time.Time{}
+	} else if table.pointInTimeRecovery.IsZero() {
+		table.pointInTimeRecovery = time.Now()
+	}
+	return &dynamodb.UpdateContinuousBackupsOutput{ContinuousBackupsDescription: table.continuousBackups()}, nil
+}
+
+func (table *fakeTable) continuousBackups() *types.ContinuousBackupsDescription {
+	description := &types.ContinuousBackupsDescription{
+		ContinuousBackupsStatus:        types.ContinuousBackupsStatusEnabled,
+		PointInTimeRecoveryDescription: &types.PointInTimeRecoveryDescription{PointInTimeRecoveryStatus: types.PointInTimeRecoveryStatusDisabled},
+	}
+	if !table.pointInTimeRecovery.IsZero() {
+		description.PointInTimeRecoveryDescription = &types.PointInTimeRecoveryDescription{
+			PointInTimeRecoveryStatus:  types.PointInTimeRecoveryStatusEnabled,
+			EarliestRestorableDateTime: aws.Time(table.pointInTimeRecovery),
+			LatestRestorableDateTime:   aws.Time(time.Now()),
+		}
+	}
+	return description
+}
@@ -0,0 +1,778 @@
+package internal
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/aws/smithy-go"
+)
+
+// FakeDynamodb is an in-memory DynamodbAPI for tests. It supports key schemas,
+// global and local secondary indexes, key condition, filter, condition,
+// projection and update (SET and REMOVE) expressions, and pagination.
+// Tables are created with CreateTable like on a real client.
+type FakeDynamodb struct {
+	// PageSize limits the items evaluated per Query or Scan page to exercise pagination
+	PageSize int
+
+	mutex   sync.Mutex
+	tables  map[string]*fakeTable
+	backups map[string]*fakeBackup // by arn
+}
+
+type fakeTable struct {
+	description  types.TableDescription
+	items        map[string]Item // by primary key
+	ttlAttribute string
+	records      []streamstypes.Record // of the stream, when enabled
+	// pointInTimeRecovery is when point in time recovery was enabled, zero when disabled
+	pointInTimeRecovery time.Time
+}
+
+var _ DynamodbAPI = (*FakeDynamodb)(nil)
+
+func NewFakeDynamodb() *FakeDynamodb {
+	return &FakeDynamodb{tables: make(map[string]*fakeTable), backups: make(map[string]*fakeBackup)}
+}
+
+// fakeValidationError mimics the ValidationException dynamodb returns for invalid requests
+func fakeValidationError(format string, args ...any) error {
+	return &smithy.GenericAPIError{Code: "ValidationException", Message: fmt.Sprintf(format, args...)}
+}
+
+func (f *FakeDynamodb) table(tableName *string) (*fakeTable, error) {
+	table, found := f.tables[aws.ToString(tableName)]
+	if !found {
+		return nil, &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Requested resource not found: Table: %s not found", aws.ToString(tableName)))}
+	}
+	return table, nil
+}
+
+func (f *FakeDynamodb) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	tableName := aws.ToString(params.TableName)
+	if _, found := f.tables[tableName]; found {
+		return nil, &types.ResourceInUseException{Message: aws.String(fmt.Sprintf("Table already exists: %s", tableName))}
+	}
+	if len(params.KeySchema) == 0 {
+		return nil, fakeValidationError("KeySchema is required")
+	}
+	description := types.TableDescription{
+		TableName:                 params.TableName,
+		TableArn:                  aws.String("arn:aws:dynamodb:local:000000000000:table/" + tableName),
+		TableStatus:               types.TableStatusActive,
+		KeySchema:                 params.KeySchema,
+		AttributeDefinitions:      params.AttributeDefinitions,
+		CreationDateTime:          aws.Time(time.Now()),
+		StreamSpecification:       params.StreamSpecification,
+		DeletionProtectionEnabled: params.DeletionProtectionEnabled,
+		BillingModeSummary:        &types.BillingModeSummary{BillingMode: params.BillingMode},
+		ProvisionedThroughput:     provisionedThroughputDescription(params.ProvisionedThroughput),
+	}
+	for _, index := range params.GlobalSecondaryIndexes {
+		description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:             index.IndexName,
+			IndexArn:              aws.String(*description.TableArn + "/index/" + aws.ToString(index.IndexName)),
+			IndexStatus:           types.IndexStatusActive,
+			KeySchema:             index.KeySchema,
+			Projection:            index.Projection,
+			ProvisionedThroughput: provisionedThroughputDescription(index.ProvisionedThroughput),
+		})
+	}
+	for _, index := range params.LocalSecondaryIndexes {
+		description.LocalSecondaryIndexes = append(description.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+			IndexName:  index.IndexName,
+			IndexArn:   aws.String(*description.TableArn + "/index/" + aws.ToString(index.IndexName)),
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		})
+	}
+	table := &fakeTable{description: description, items: make(map[string]Item)}
+	table.enableStream()
+	f.tables[tableName] = table
+	return &dynamodb.CreateTableOutput{TableDescription: &table.description}, nil
+}
+
+func provisionedThroughputDescription(throughput *types.ProvisionedThroughput) *types.ProvisionedThroughputDescription {
+	if throughput == nil {
+		return nil
+	}
+	return &types.ProvisionedThroughputDescription{
+		ReadCapacityUnits:  throughput.ReadCapacityUnits,
+		WriteCapacityUnits: throughput.WriteCapacityUnits,
+	}
+}
+
+func (f *FakeDynamodb) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	description := table.description
+	description.ItemCount = aws.Int64(int64(len(table.items)))
+	return &dynamodb.DescribeTableOutput{Table: &description}, nil
+}
+
+// UpdateTable applies changes at once, tables and indexes stay ACTIVE
+func (f *FakeDynamodb) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	onlineUpdates := 0
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		if update.Create != nil || update.Delete != nil {
+			onlineUpdates++
+		}
+	}
+	if onlineUpdates > 1 {
+		return nil, fakeValidationError("Subscriber limit exceeded: Only 1 online index can be created or deleted simultaneously per table")
+	}
+	description := &table.description
+	for _, definition := range params.AttributeDefinitions {
+		if !slices.ContainsFunc(description.AttributeDefinitions, func(existing types.AttributeDefinition) bool {
+			return *existing.AttributeName == *definition.AttributeName
+		}) {
+			description.AttributeDefinitions = append(description.AttributeDefinitions, definition)
+		}
+	}
+	if params.BillingMode != "" {
+		description.BillingModeSummary = &types.BillingModeSummary{BillingMode: params.BillingMode}
+	}
+	if params.ProvisionedThroughput != nil {
+		description.ProvisionedThroughput = provisionedThroughputDescription(params.ProvisionedThroughput)
+	}
+	if params.StreamSpecification != nil {
+		// enabling a stream starts a new one
+		if aws.ToBool(params.StreamSpecification.StreamEnabled) {
+			description.LatestStreamArn = nil
+			table.records = nil
+		}
+		description.StreamSpecification = params.StreamSpecification
+		table.enableStream()
+	}
+	if params.DeletionProtectionEnabled != nil {
+		description.DeletionProtectionEnabled = params.DeletionProtectionEnabled
+	}
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		switch {
+		case update.Create != nil:
+			index := update.Create
+			description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+				IndexName:             index.IndexName,
+				IndexArn:              aws.String(*description.TableArn + "/index/" + aws.ToString(index.IndexName)),
+				IndexStatus:           types.IndexStatusActive,
+				KeySchema:             index.KeySchema,
+				Projection:            index.Projection,
+				ProvisionedThroughput: provisionedThroughputDescription(index.ProvisionedThroughput),
+			})
+		case update.Update != nil:
+			for i, index := range description.GlobalSecondaryIndexes {
+				if *index.IndexName == aws.ToString(update.Update.IndexName) {
+					description.GlobalSecondaryIndexes[i].ProvisionedThroughput = provisionedThroughputDescription(update.Update.ProvisionedThroughput)
+				}
+			}
+		case update.Delete != nil:
+			indexName := aws.ToString(update.Delete.IndexName)
+			found := false
+			description.GlobalSecondaryIndexes = slices.DeleteFunc(description.GlobalSecondaryIndexes, func(index types.GlobalSecondaryIndexDescription) bool {
+				found = found || *index.IndexName == indexName
+				return *index.IndexName == indexName
+			})
+			if !found {
+				return nil, &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Requested resource not found: Index: %s not found", indexName))}
+			}
+		}
+	}
+	copied := *description
+	return &dynamodb.UpdateTableOutput{TableDescription: &copied}, nil
+}
+
+func (f *FakeDynamodb) DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToBool(table.description.DeletionProtectionEnabled) {
+		return nil, fakeValidationError("Resource cannot be deleted as it is currently protected against deletion. Disable deletion protection first.")
+	}
+	delete(f.tables, aws.ToString(params.TableName))
+	description := table.description
+	description.TableStatus = types.TableStatusDeleting
+	return &dynamodb.DeleteTableOutput{TableDescription: &description}, nil
+}
+
+func (f *FakeDynamodb) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	specification := params.TimeToLiveSpecification
+	if specification == nil || specification.AttributeName == nil || specification.Enabled == nil {
+		return nil, fakeValidationError("TimeToLiveSpecification needs an AttributeName and Enabled")
+	}
+	if *specification.Enabled && table.ttlAttribute != "" {
+		return nil, fakeValidationError("TimeToLive is already enabled")
+	}
+	if !*specification.Enabled && table.ttlAttribute == "" {
+		return nil, fakeValidationError("TimeToLive is already disabled")
+	}
+	table.ttlAttribute = ""
+	if *specification.Enabled {
+		table.ttlAttribute = *specification.AttributeName
+	}
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: specification}, nil
+}
+
+func (f *FakeDynamodb) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	description := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if table.ttlAttribute != "" {
+		description = &types.TimeToLiveDescription{
+			AttributeName:    aws.String(table.ttlAttribute),
+			TimeToLiveStatus: types.TimeToLiveStatusEnabled,
+		}
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: description}, nil
+}
+
+func (f *FakeDynamodb) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var tableNames []string
+	for tableName := range f.tables {
+		if tableName > aws.ToString(params.ExclusiveStartTableName) {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	slices.Sort(tableNames)
+	output := &dynamodb.ListTablesOutput{}
+	limit := int(aws.ToInt32(params.Limit))
+	if limit == 0 {
+		limit = 100
+	}
+	if len(tableNames) > limit {
+		tableNames = tableNames[:limit]
+		output.LastEvaluatedTableName = aws.String(tableNames[limit-1])
+	}
+	output.TableNames = tableNames
+	return output, nil
+}
+
+func (f *FakeDynamodb) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.primaryKey(params.Key, true)
+	if err != nil {
+		return nil, err
+	}
+	item, found := table.items[key]
+	if !found {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	item, err = project(item, params.ProjectionExpression, params.ExpressionAttributeNames)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: item}, nil
+}
+
+func (f *FakeDynamodb) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.primaryKey(params.Item, false)
+	if err != nil {
+		return nil, err
+	}
+	existing := table.items[key]
+	if err := checkCondition(existing, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	table.write(key, copyItem(params.Item))
+	output := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = existing
+	}
+	return output, nil
+}
+
+func (f *FakeDynamodb) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := table.primaryKey(params.Key, true)
+	if err != nil {
+		return nil, err
+	}
+	existing := table.items[key]
+	if err := checkCondition(existing, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	table.write(key, nil)
+	output := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		output.Attributes = existing
+	}
+	return output, nil
+}
+
+func (f *FakeDynamodb) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if params.KeyConditionExpression == nil {
+		return nil, fakeValidationError("KeyConditionExpression is required")
+	}
+	keyCondition, err := parseCondition(*params.KeyConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fakeValidationError("invalid KeyConditionExpression: %s", err)
+	}
+	page, err := table.read(readInput{
+		indexName:         params.IndexName,
+		keyCondition:      keyCondition,
+		filterExpression:  params.FilterExpression,
+		projection:        params.ProjectionExpression,
+		names:             params.ExpressionAttributeNames,
+		values:            params.ExpressionAttributeValues,
+		exclusiveStartKey: params.ExclusiveStartKey,
+		limit:             f.limit(params.Limit),
+		backwards:         params.ScanIndexForward != nil && !*params.ScanIndexForward,
+	})
+	if err != nil {
+		return nil, err
+	}
+	output := &dynamodb.QueryOutput{
+		Count:            page.count,
+		ScannedCount:     page.scannedCount,
+		LastEvaluatedKey: page.lastEvaluatedKey,
+		ConsumedCapacity: table.readCapacity(params.ReturnConsumedCapacity, params.IndexName, page.scannedCount, params.ConsistentRead),
+	}
+	if params.Select != types.SelectCount {
+		output.Items = page.items
+	}
+	return output, nil
+}
+
+func (f *FakeDynamodb) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	page, err := table.read(readInput{
+		indexName:         params.IndexName,
+		filterExpression:  params.FilterExpression,
+		projection:        params.ProjectionExpression,
+		names:             params.ExpressionAttributeNames,
+		values:            params.ExpressionAttributeValues,
+		exclusiveStartKey: params.ExclusiveStartKey,
+		limit:             f.limit(params.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	output := &dynamodb.ScanOutput{
+		Count:            page.count,
+		ScannedCount:     page.scannedCount,
+		LastEvaluatedKey: page.lastEvaluatedKey,
+		ConsumedCapacity: table.readCapacity(params.ReturnConsumedCapacity, params.IndexName, page.scannedCount, params.ConsistentRead),
+	}
+	if params.Select != types.SelectCount {
+		output.Items = page.items
+	}
+	return output, nil
+}
+
+func (f *FakeDynamodb) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	type write struct {
+		table *fakeTable
+		key   string
+		item  Item // nil deletes the item
+	}
+	var writes []write
+	var reasons []types.CancellationReason
+	canceled := false
+	written := make(map[*fakeTable]map[string]bool) // keys of the items of the operations
+	for _, transactItem := range params.TransactItems {
+		var tableName, conditionExpression, updateExpression *string
+		var keyValues Item
+		var names map[string]string
+		var values map[string]types.AttributeValue
+		switch {
+		case transactItem.Put != nil:
+			operation := transactItem.Put
+			tableName, keyValues, conditionExpression, names, values = operation.TableName, operation.Item, operation.ConditionExpression, operation.ExpressionAttributeNames, operation.ExpressionAttributeValues
+		case transactItem.Update != nil:
+			operation := transactItem.Update
+			tableName, keyValues, conditionExpression, names, values = operation.TableName, operation.Key, operation.ConditionExpression, operation.ExpressionAttributeNames, operation.ExpressionAttributeValues
+			updateExpression = operation.UpdateExpression
+		case transactItem.Delete != nil:
+			operation := transactItem.Delete
+			tableName, keyValues, conditionExpression, names, values = operation.TableName, operation.Key, operation.ConditionExpression, operation.ExpressionAttributeNames, operation.ExpressionAttributeValues
+		case transactItem.ConditionCheck != nil:
+			operation := transactItem.ConditionCheck
+			tableName, keyValues, conditionExpression, names, values = operation.TableName, operation.Key, operation.ConditionExpression, operation.ExpressionAttributeNames, operation.ExpressionAttributeValues
+		default:
+			return nil, fakeValidationError("TransactItems must have one of Put, Update, Delete or ConditionCheck")
+		}
+		table, err := f.table(tableName)
+		if err != nil {
+			return nil, err
+		}
+		key, err := table.primaryKey(keyValues, transactItem.Put == nil)
+		if err != nil {
+			return nil, err
+		}
+		if written[table] == nil {
+			written[table] = make(map[string]bool)
+		}
+		if written[table][key] {
+			return nil, fakeValidationError("Transaction request cannot include multiple operations on one item")
+		}
+		written[table][key] = true
+		existing := table.items[key]
+		reason := types.CancellationReason{Code: aws.String("None")}
+		if err := checkCondition(existing, conditionExpression, names, values); err != nil {
+			reason = types.CancellationReason{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")}
+			canceled = true
+		}
+		reasons = append(reasons, reason)
+
+		switch {
+		case transactItem.Put != nil:
+			writes = append(writes, write{table, key, copyItem(keyValues)})
+		case transactItem.Update != nil:
+			item := copyItem(existing)
+			if item == nil {
+				item = copyItem(keyValues)
+			}
+			if err := applyUpdate(item, aws.ToString(updateExpression), names, values); err != nil {
+				return nil, fakeValidationError("invalid UpdateExpression: %s", err)
+			}
+			writes = append(writes, write{table, key, item})
+		case transactItem.Delete != nil:
+			writes = append(writes, write{table, key, nil})
+		}
+	}
+	if canceled {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, write := range writes {
+		write.table.write(write.key, write.item)
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (f *FakeDynamodb) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	return nil, fakeValidationError("PartiQL statements are not supported by FakeDynamodb")
+}
+
+func (f *FakeDynamodb) BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	return nil, fakeValidationError("PartiQL statements are not supported by FakeDynamodb")
+}
+
+func (f *FakeDynamodb) limit(limit *int32) int {
+	pageLimit := int(aws.ToInt32(limit))
+	if f.PageSize > 0 && (pageLimit == 0 || f.PageSize < pageLimit) {
+		pageLimit = f.PageSize
+	}
+	return pageLimit
+}
+
+func checkCondition(item Item, expression *string, names map[string]string, values map[string]types.AttributeValue) error {
+	if expression == nil {
+		return nil
+	}
+	c, err := parseCondition(*expression, names, values)
+	if err != nil {
+		return fakeValidationError("invalid ConditionExpression: %s", err)
+	}
+	if item == nil {
+		item = make(Item)
+	}
+	if !c(item) {
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	return nil
+}
+
+func project(item Item, expression *string, names map[string]string) (Item, error) {
+	if expression == nil {
+		return item, nil
+	}
+	paths, err := parseProjection(*expression, names)
+	if err != nil {
+		return nil, fakeValidationError("invalid ProjectionExpression: %s", err)
+	}
+	return projectItem(item, paths), nil
+}
+
+// primaryKey validates the key attributes of an item and encodes them as a map key,
+// exact requires the item to have only the key attributes
+func (table *fakeTable) primaryKey(item Item, exact bool) (string, error) {
+	if exact && len(item) != len(table.description.KeySchema) {
+		return "", fakeValidationError("The provided key element does not match the schema")
+	}
+	return encodeKey(item, table.description.KeySchema, table.description.AttributeDefinitions)
+}
+
+func encodeKey(item Item, keySchema []types.KeySchemaElement, definitions []types.AttributeDefinition) (string, error) {
+	var encoded []string
+	for _, key := range keySchema {
+		value, found := item[aws.ToString(key.AttributeName)]
+		if !found {
+			return "", fakeValidationError("One of the required keys was not given a value")
+		}
+		valueType := attributeType(value)
+		for _, definition := range definitions {
+			if aws.ToString(definition.AttributeName) == aws.ToString(key.AttributeName) && string(definition.AttributeType) != valueType {
+				return "", fakeValidationError("Type mismatch for key %s expected: %s actual: %s", aws.ToString(key.AttributeName), definition.AttributeType, valueType)
+			}
+		}
+		encoded = append(encoded, valueType+":"+keyText(value))
+	}
+	return strings.Join(encoded, "|"), nil
+}
+
+// keyText is the text of a scalar key value, numbers are normalized so 1 and 1.0 are the same key
+func keyText(value types.AttributeValue) string {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return value.Value
+	case *types.AttributeValueMemberN:
+		if number, _, err := big.ParseFloat(value.Value, 10, 256, big.ToNearestEven); err == nil {
+			return number.Text('g', -1)
+		}
+		return value.Value
+	case *types.AttributeValueMemberB:
+		return hex.EncodeToString(value.Value)
+	}
+	return fmt.Sprint(value)
+}
+
+type readInput struct {
+	indexName         *string
+	keyCondition      condition
+	filterExpression  *string
+	projection        *string
+	names             map[string]string
+	values            map[string]types.AttributeValue
+	exclusiveStartKey Item
+	limit             int
+	backwards         bool
+}
+
+type readPage struct {
+	items            []map[string]types.AttributeValue
+	count            int32
+	scannedCount     int32
+	lastEvaluatedKey Item
+}
+
+// readCapacity charges half a unit per scanned item, or a unit for consistent
+// reads, as if every item was under 4KB
+func (table *fakeTable) readCapacity(mode types.ReturnConsumedCapacity, indexName *string, scanned int32, consistentRead *bool) *types.ConsumedCapacity {
+	if mode == "" || mode == types.ReturnConsumedCapacityNone {
+		return nil
+	}
+	units := float64(scanned) / 2
+	if consistentRead != nil && *consistentRead {
+		units = float64(scanned)
+	}
+	consumed := &types.ConsumedCapacity{TableName: table.description.TableName, CapacityUnits: aws.Float64(units)}
+	if mode != types.ReturnConsumedCapacityIndexes {
+		return consumed
+	}
+	if indexName == nil {
+		consumed.Table = &types.Capacity{CapacityUnits: aws.Float64(units)}
+		return consumed
+	}
+	consumed.Table = &types.Capacity{CapacityUnits: aws.Float64(0)}
+	indexCapacity := map[string]types.Capacity{*indexName: {CapacityUnits: aws.Float64(units)}}
+	for _, index := range table.description.LocalSecondaryIndexes {
+		if *index.IndexName == *indexName {
+			consumed.LocalSecondaryIndexes = indexCapacity
+			return consumed
+		}
+	}
+	consumed.GlobalSecondaryIndexes = indexCapacity
+	return consumed
+}
+
+// projectIndex returns the attributes of an item held by an index: every one
+// for ALL, else the index and table keys plus the INCLUDE non-key attributes
+func projectIndex(item Item, projection *types.Projection, keySchema []types.KeySchemaElement) Item {
+	if projection == nil || projection.ProjectionType == "" || projection.ProjectionType == types.ProjectionTypeAll {
+		return item
+	}
+	projected := make(Item)
+	for _, key := range keySchema {
+		projected[*key.AttributeName] = item[*key.AttributeName]
+	}
+	if projection.ProjectionType == types.ProjectionTypeInclude {
+		for _, name := range projection.NonKeyAttributes {
+			if value, found := item[name]; found {
+				projected[name] = value
+			}
+		}
+	}
+	return projected
+}
+
+// read evaluates a query or scan page against the table or one of its indexes
+func (table *fakeTable) read(input readInput) (readPage, error) {
+	keySchema := table.description.KeySchema
+	var indexProjection *types.Projection
+	if input.indexName != nil {
+		keySchema = nil
+		for _, index := range table.description.GlobalSecondaryIndexes {
+			if aws.ToString(index.IndexName) == *input.indexName {
+				keySchema, indexProjection = index.KeySchema, index.Projection
+			}
+		}
+		for _, index := range table.description.LocalSecondaryIndexes {
+			if aws.ToString(index.IndexName) == *input.indexName {
+				keySchema, indexProjection = index.KeySchema, index.Projection
+			}
+		}
+		if keySchema == nil {
+			return readPage{}, fakeValidationError("The table does not have the specified index: %s", *input.indexName)
+		}
+	}
+	var filter condition
+	if input.filterExpression != nil {
+		var err error
+		filter, err = parseCondition(*input.filterExpression, input.names, input.values)
+		if err != nil {
+			return readPage{}, fakeValidationError("invalid FilterExpression: %s", err)
+		}
+	}
+
+	// items without the index keys aren't in the index
+	var items []Item
+	for _, item := range table.items {
+		if _, err := encodeKey(item, keySchema, nil); err == nil {
+			items = append(items, item)
+		}
+	}
+	// order by index keys, then table keys to keep index pages stable
+	orderSchema := slices.Concat(keySchema, table.description.KeySchema)
+	compareItems := func(a, b Item) int {
+		for _, key := range orderSchema {
+			if compared, _ := compareAttributeValues(a[*key.AttributeName], b[*key.AttributeName]); compared != 0 {
+				return compared
+			}
+		}
+		return 0
+	}
+	slices.SortFunc(items, compareItems)
+	if input.backwards {
+		slices.Reverse(items)
+	}
+
+	start := 0
+	if input.exclusiveStartKey != nil {
+		if _, err := encodeKey(input.exclusiveStartKey, orderSchema, nil); err != nil {
+			return readPage{}, fakeValidationError("The provided starting key is invalid")
+		}
+		// resume after the key like dynamodb, also when its item was deleted since
+		start = slices.IndexFunc(items, func(item Item) bool {
+			if input.backwards {
+				return compareItems(item, input.exclusiveStartKey) < 0
+			}
+			return compareItems(item, input.exclusiveStartKey) > 0
+		})
+		if start < 0 {
+			start = len(items)
+		}
+	}
+
+	var page readPage
+	for _, item := range items[start:] {
+		if input.keyCondition != nil && !input.keyCondition(item) {
+			continue
+		}
+		// an index only holds its projected attributes, filters don't see the others
+		item = projectIndex(item, indexProjection, orderSchema)
+		page.scannedCount++
+		if filter == nil || filter(item) {
+			projected, err := project(item, input.projection, input.names)
+			if err != nil {
+				return readPage{}, err
+			}
+			page.items = append(page.items, copyItem(projected))
+			page.count++
+		}
+		if input.limit > 0 && int(page.scannedCount) == input.limit {
+			page.lastEvaluatedKey = make(Item)
+			for _, key := range orderSchema {
+				page.lastEvaluatedKey[*key.AttributeName] = item[*key.AttributeName]
+			}
+			break
+		}
+	}
+	return page, nil
+}
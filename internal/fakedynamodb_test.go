@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// newFakeNumbers returns a fake with a numbers table keyed by n holding 1 to count
+func newFakeNumbers(t *testing.T, count int) *FakeDynamodb {
+	t.Helper()
+	fake := NewFakeDynamodb()
+	_, err := fake.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
+		TableName:            aws.String("numbers"),
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("n"), AttributeType: types.ScalarAttributeTypeN}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("n"), KeyType: types.KeyTypeHash}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= count; n++ {
+		if err := PutItem(context.TODO(), fake, "numbers", Item{"n": &types.AttributeValueMemberN{Value: strconv.Itoa(n)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fake
+}
+
+func TestFakeResumesAfterDeletedStartKey(t *testing.T) {
+	fake := newFakeNumbers(t, 4)
+	page, err := fake.Scan(context.TODO(), &dynamodb.ScanInput{TableName: aws.String("numbers"), Limit: aws.Int32(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last item of the page is deleted before the next page is read
+	if err := DeleteItem(context.TODO(), fake, "numbers", page.LastEvaluatedKey); err != nil {
+		t.Fatal(err)
+	}
+	page, err = fake.Scan(context.TODO(), &dynamodb.ScanInput{TableName: aws.String("numbers"), ExclusiveStartKey: page.LastEvaluatedKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []string
+	for _, item := range page.Items {
+		numbers = append(numbers, item["n"].(*types.AttributeValueMemberN).Value)
+	}
+	if len(numbers) != 2 || numbers[0] != "3" || numbers[1] != "4" {
+		t.Errorf("got %v after the deleted start key, want 3 and 4", numbers)
+	}
+}
+
+func TestFakeTransactRejectsSameItem(t *testing.T) {
+	fake := newFakeNumbers(t, 1)
+	key := Item{"n": &types.AttributeValueMemberN{Value: "1"}}
+	_, err := fake.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+		{Put: &types.Put{TableName: aws.String("numbers"), Item: Item{"n": key["n"], "a": &types.AttributeValueMemberS{Value: "x"}}}},
+		{Delete: &types.Delete{TableName: aws.String("numbers"), Key: Item{"n": &types.AttributeValueMemberN{Value: "1.0"}}}},
+	}})
+	if err == nil {
+		t.Fatalf("got no error for two operations on one item")
+	}
+	if item, err := GetItem(context.TODO(), fake, "numbers", key); err != nil || item["a"] != nil {
+		t.Errorf("got %v, %v want the item unchanged", item, err)
+	}
+}
+
+func TestFakeIndexProjection(t *testing.T) {
+	fake := NewFakeDynamodb()
+	index := func(name string, projection types.Projection) types.GlobalSecondaryIndex {
+		return types.GlobalSecondaryIndex{
+			IndexName:  aws.String(name),
+			KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("status"), KeyType: types.KeyTypeHash}},
+			Projection: &projection,
+		}
+	}
+	_, err := fake.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
+		TableName: aws.String("orders"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("status"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			index("keys", types.Projection{ProjectionType: types.ProjectionTypeKeysOnly}),
+			index("include", types.Projection{ProjectionType: types.ProjectionTypeInclude, NonKeyAttributes: []string{"total"}}),
+			index("all", types.Projection{ProjectionType: types.ProjectionTypeAll}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := Item{
+		"id":     &types.AttributeValueMemberS{Value: "a"},
+		"status": &types.AttributeValueMemberS{Value: "open"},
+		"total":  &types.AttributeValueMemberN{Value: "5"},
+		"note":   &types.AttributeValueMemberS{Value: "x"},
+	}
+	if err := PutItem(context.TODO(), fake, "orders", item); err != nil {
+		t.Fatal(err)
+	}
+	for indexName, want := range map[string][]string{
+		"keys":    {"id", "status"},
+		"include": {"id", "status", "total"},
+		"all":     {"id", "status", "total", "note"},
+	} {
+		query, err := fake.Query(context.TODO(), &dynamodb.QueryInput{
+			TableName:                 aws.String("orders"),
+			IndexName:                 aws.String(indexName),
+			KeyConditionExpression:    aws.String("#s = :s"),
+			ExpressionAttributeNames:  map[string]string{"#s": "status"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":s": item["status"]},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		scan, err := fake.Scan(context.TODO(), &dynamodb.ScanInput{TableName: aws.String("orders"), IndexName: aws.String(indexName)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for operation, items := range map[string][]map[string]types.AttributeValue{"query": query.Items, "scan": scan.Items} {
+			if len(items) != 1 || len(items[0]) != len(want) {
+				t.Errorf("%s of %s got %v, want the attributes %v", operation, indexName, items, want)
+				continue
+			}
+			for _, name := range want {
+				if items[0][name] == nil {
+					t.Errorf("%s of %s got %v without %s", operation, indexName, items[0], name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,826 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// This file evaluates dynamodb expression strings for FakeDynamodb. It covers
+// condition, filter and key condition expressions, projection expressions, and
+// the SET and REMOVE clauses of update expressions.
+
+type condition func(item Item) bool
+
+type operand func(item Item) (types.AttributeValue, bool)
+
+type pathElement struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+type attributePath []pathElement
+
+type expressionToken struct {
+	text    string
+	isName  bool // an attribute name, function name or keyword
+	isValue bool // a :value placeholder
+}
+
+type expressionParser struct {
+	tokens   []expressionToken
+	position int
+	names    map[string]string
+	values   map[string]types.AttributeValue
+}
+
+func tokenizeExpression(expression string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(expression)
+	isNameRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+	for index := 0; index < len(runes); {
+		r := runes[index]
+		switch {
+		case unicode.IsSpace(r):
+			index++
+		case r == '#' || r == ':' || isNameRune(r):
+			start := index
+			index++
+			for index < len(runes) && isNameRune(runes[index]) {
+				index++
+			}
+			text := string(runes[start:index])
+			tokens = append(tokens, expressionToken{text: text, isName: r != ':', isValue: r == ':'})
+		case strings.ContainsRune("()[],.=+-", r):
+			tokens = append(tokens, expressionToken{text: string(r)})
+			index++
+		case r == '<' || r == '>':
+			text := string(r)
+			index++
+			if index < len(runes) && (runes[index] == '=' || (r == '<' && runes[index] == '>')) {
+				text += string(runes[index])
+				index++
+			}
+			tokens = append(tokens, expressionToken{text: text})
+		default:
+			return nil, fmt.Errorf("invalid character %q in expression %s", r, expression)
+		}
+	}
+	return tokens, nil
+}
+
+func newExpressionParser(expression string, names map[string]string, values map[string]types.AttributeValue) (*expressionParser, error) {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &expressionParser{tokens: tokens, names: names, values: values}, nil
+}
+
+func (p *expressionParser) peek() expressionToken {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+	return expressionToken{}
+}
+
+func (p *expressionParser) next() expressionToken {
+	token := p.peek()
+	p.position++
+	return token
+}
+
+func (p *expressionParser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+// keyword reports whether the next token is the given case-insensitive keyword
+func (p *expressionParser) keyword(keyword string) bool {
+	token := p.peek()
+	return token.isName && strings.EqualFold(token.text, keyword)
+}
+
+func (p *expressionParser) expect(text string) error {
+	if token := p.next(); token.text != text {
+		return fmt.Errorf("expected %q in expression but found %q", text, token.text)
+	}
+	return nil
+}
+
+// isFunction reports whether the next tokens are a call of the named function
+func (p *expressionParser) isFunction(name string) bool {
+	return p.keyword(name) && p.position+1 < len(p.tokens) && p.tokens[p.position+1].text == "("
+}
+
+// parseCondition parses a condition, filter or key condition expression
+func parseCondition(expression string, names map[string]string, values map[string]types.AttributeValue) (condition, error) {
+	p, err := newExpressionParser(expression, names, values)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in expression %s", p.peek().text, expression)
+	}
+	return c, nil
+}
+
+func (p *expressionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = func(l, r condition) condition {
+			return func(item Item) bool { return l(item) || r(item) }
+		}(left, right)
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = func(l, r condition) condition {
+			return func(item Item) bool { return l(item) && r(item) }
+		}(left, right)
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseNot() (condition, error) {
+	if p.keyword("NOT") {
+		p.next()
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(item Item) bool { return !c(item) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (condition, error) {
+	if p.peek().text == "(" {
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return c, p.expect(")")
+	}
+	for _, function := range []string{"attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains"} {
+		if p.isFunction(function) {
+			return p.parseFunction(function)
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.keyword("BETWEEN"):
+		p.next()
+		low, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, fmt.Errorf("expected AND in BETWEEN")
+		}
+		p.next()
+		high, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item Item) bool {
+			value, found := left(item)
+			lowValue, lowFound := low(item)
+			highValue, highFound := high(item)
+			if !found || !lowFound || !highFound {
+				return false
+			}
+			lowCompare, lowOk := compareAttributeValues(value, lowValue)
+			highCompare, highOk := compareAttributeValues(value, highValue)
+			return lowOk && highOk && lowCompare >= 0 && highCompare <= 0
+		}, nil
+	case p.keyword("IN"):
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var candidates []operand
+		for {
+			candidate, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, candidate)
+			if p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return func(item Item) bool {
+			value, found := left(item)
+			if !found {
+				return false
+			}
+			for _, candidate := range candidates {
+				if candidateValue, candidateFound := candidate(item); candidateFound && equalAttributeValues(value, candidateValue) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	comparator := p.next().text
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch comparator {
+	case "=", "<>":
+		equal := comparator == "="
+		return func(item Item) bool {
+			leftValue, leftFound := left(item)
+			rightValue, rightFound := right(item)
+			if !leftFound || !rightFound {
+				return !equal
+			}
+			return equalAttributeValues(leftValue, rightValue) == equal
+		}, nil
+	case "<", "<=", ">", ">=":
+		return func(item Item) bool {
+			leftValue, leftFound := left(item)
+			rightValue, rightFound := right(item)
+			if !leftFound || !rightFound {
+				return false
+			}
+			compared, ok := compareAttributeValues(leftValue, rightValue)
+			if !ok {
+				return false
+			}
+			switch comparator {
+			case "<":
+				return compared < 0
+			case "<=":
+				return compared <= 0
+			case ">":
+				return compared > 0
+			}
+			return compared >= 0
+		}, nil
+	}
+	return nil, fmt.Errorf("expected a comparator in expression but found %q", comparator)
+}
+
+func (p *expressionParser) parseFunction(function string) (condition, error) {
+	p.next()
+	p.next() // (
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	var argument operand
+	if function != "attribute_exists" && function != "attribute_not_exists" {
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if argument, err = p.parseOperand(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return func(item Item) bool {
+		value, found := path.resolve(item)
+		switch function {
+		case "attribute_exists":
+			return found
+		case "attribute_not_exists":
+			return !found
+		}
+		argumentValue, argumentFound := argument(item)
+		if !found || !argumentFound {
+			return false
+		}
+		switch function {
+		case "attribute_type":
+			typeName, ok := argumentValue.(*types.AttributeValueMemberS)
+			return ok && attributeType(value) == typeName.Value
+		case "begins_with":
+			switch value := value.(type) {
+			case *types.AttributeValueMemberS:
+				prefix, ok := argumentValue.(*types.AttributeValueMemberS)
+				return ok && strings.HasPrefix(value.Value, prefix.Value)
+			case *types.AttributeValueMemberB:
+				prefix, ok := argumentValue.(*types.AttributeValueMemberB)
+				return ok && bytes.HasPrefix(value.Value, prefix.Value)
+			}
+			return false
+		}
+		// contains
+		switch value := value.(type) {
+		case *types.AttributeValueMemberS:
+			substring, ok := argumentValue.(*types.AttributeValueMemberS)
+			return ok && strings.Contains(value.Value, substring.Value)
+		case *types.AttributeValueMemberSS:
+			member, ok := argumentValue.(*types.AttributeValueMemberS)
+			return ok && slices.Contains(value.Value, member.Value)
+		case *types.AttributeValueMemberNS:
+			member, ok := argumentValue.(*types.AttributeValueMemberN)
+			return ok && slices.ContainsFunc(value.Value, func(n string) bool {
+				return equalAttributeValues(&types.AttributeValueMemberN{Value: n}, member)
+			})
+		case *types.AttributeValueMemberBS:
+			member, ok := argumentValue.(*types.AttributeValueMemberB)
+			return ok && slices.ContainsFunc(value.Value, func(b []byte) bool { return bytes.Equal(b, member.Value) })
+		case *types.AttributeValueMemberL:
+			return slices.ContainsFunc(value.Value, func(element types.AttributeValue) bool {
+				return equalAttributeValues(element, argumentValue)
+			})
+		}
+		return false
+	}, nil
+}
+
+func (p *expressionParser) parseOperand() (operand, error) {
+	token := p.peek()
+	if token.isValue {
+		p.next()
+		value, found := p.values[token.text]
+		if !found {
+			return nil, fmt.Errorf("value %s is not defined in ExpressionAttributeValues", token.text)
+		}
+		return func(Item) (types.AttributeValue, bool) { return value, true }, nil
+	}
+	if p.isFunction("size") {
+		p.next()
+		p.next() // (
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return func(item Item) (types.AttributeValue, bool) {
+			value, found := path.resolve(item)
+			if !found {
+				return nil, false
+			}
+			size, ok := attributeSize(value)
+			if !ok {
+				return nil, false
+			}
+			return &types.AttributeValueMemberN{Value: strconv.Itoa(size)}, true
+		}, nil
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return path.resolve, nil
+}
+
+func (p *expressionParser) parseName() (string, error) {
+	token := p.next()
+	if !token.isName {
+		return "", fmt.Errorf("expected an attribute name in expression but found %q", token.text)
+	}
+	if strings.HasPrefix(token.text, "#") {
+		name, found := p.names[token.text]
+		if !found {
+			return "", fmt.Errorf("name %s is not defined in ExpressionAttributeNames", token.text)
+		}
+		return name, nil
+	}
+	return token.text, nil
+}
+
+func (p *expressionParser) parsePath() (attributePath, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	path := attributePath{{name: name}}
+	for {
+		switch p.peek().text {
+		case ".":
+			p.next()
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, pathElement{name: name})
+		case "[":
+			p.next()
+			index, err := strconv.Atoi(p.next().text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid list index in expression [%w]", err)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			path = append(path, pathElement{index: index, isIndex: true})
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (path attributePath) resolve(item Item) (types.AttributeValue, bool) {
+	var value types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for _, element := range path {
+		switch current := value.(type) {
+		case *types.AttributeValueMemberM:
+			if element.isIndex {
+				return nil, false
+			}
+			next, found := current.Value[element.name]
+			if !found {
+				return nil, false
+			}
+			value = next
+		case *types.AttributeValueMemberL:
+			if !element.isIndex || element.index >= len(current.Value) {
+				return nil, false
+			}
+			value = current.Value[element.index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// set stores a value at the path, the parent of the last element must exist
+func (path attributePath) set(item Item, value types.AttributeValue) error {
+	parent, found := path[:len(path)-1].resolve(item)
+	if !found {
+		return fmt.Errorf("the document path provided in the update expression is invalid for update")
+	}
+	last := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *types.AttributeValueMemberM:
+		if !last.isIndex {
+			parent.Value[last.name] = value
+			return nil
+		}
+	case *types.AttributeValueMemberL:
+		if last.isIndex {
+			if last.index < len(parent.Value) {
+				parent.Value[last.index] = value
+			} else {
+				parent.Value = append(parent.Value, value)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("the document path provided in the update expression is invalid for update")
+}
+
+func (path attributePath) remove(item Item) {
+	parent, found := path[:len(path)-1].resolve(item)
+	if !found {
+		return
+	}
+	last := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *types.AttributeValueMemberM:
+		delete(parent.Value, last.name)
+	case *types.AttributeValueMemberL:
+		if last.isIndex && last.index < len(parent.Value) {
+			parent.Value = slices.Delete(parent.Value, last.index, last.index+1)
+		}
+	}
+}
+
+// parseProjection parses a projection expression into its attribute paths
+func parseProjection(expression string, names map[string]string) ([]attributePath, error) {
+	p, err := newExpressionParser(expression, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []attributePath
+	for {
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if p.done() {
+			return paths, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// projectItem copies the projected paths of an item, paths into lists
+// project the whole list
+func projectItem(item Item, paths []attributePath) Item {
+	projected := make(Item)
+	for _, path := range paths {
+		if index := slices.IndexFunc(path, func(element pathElement) bool { return element.isIndex }); index >= 0 {
+			path = path[:index]
+		}
+		value, found := path.resolve(item)
+		if !found {
+			continue
+		}
+		target := projected
+		for _, element := range path[:len(path)-1] {
+			child, ok := target[element.name].(*types.AttributeValueMemberM)
+			if !ok {
+				child = &types.AttributeValueMemberM{Value: make(Item)}
+				target[element.name] = child
+			}
+			target = child.Value
+		}
+		target[path[len(path)-1].name] = value
+	}
+	return projected
+}
+
+// applyUpdate applies the SET and REMOVE clauses of an update expression to an item in place
+func applyUpdate(item Item, expression string, names map[string]string, values map[string]types.AttributeValue) error {
+	p, err := newExpressionParser(expression, names, values)
+	if err != nil {
+		return err
+	}
+	for !p.done() {
+		switch {
+		case p.keyword("SET"):
+			p.next()
+			for {
+				path, err := p.parsePath()
+				if err != nil {
+					return err
+				}
+				if err := p.expect("="); err != nil {
+					return err
+				}
+				value, err := p.parseUpdateValue()
+				if err != nil {
+					return err
+				}
+				newValue, found := value(item)
+				if !found {
+					return fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+				}
+				if err := path.set(item, newValue); err != nil {
+					return err
+				}
+				if p.peek().text != "," {
+					break
+				}
+				p.next()
+			}
+		case p.keyword("REMOVE"):
+			p.next()
+			for {
+				path, err := p.parsePath()
+				if err != nil {
+					return err
+				}
+				path.remove(item)
+				if p.peek().text != "," {
+					break
+				}
+				p.next()
+			}
+		default:
+			return fmt.Errorf("unsupported update clause %q, only SET and REMOVE are supported", p.peek().text)
+		}
+	}
+	return nil
+}
+
+func (p *expressionParser) parseUpdateValue() (operand, error) {
+	var left operand
+	var err error
+	switch {
+	case p.isFunction("if_not_exists"):
+		p.next()
+		p.next() // (
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		fallback, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		left = func(item Item) (types.AttributeValue, bool) {
+			if value, found := path.resolve(item); found {
+				return value, true
+			}
+			return fallback(item)
+		}
+	case p.isFunction("list_append"):
+		p.next()
+		p.next() // (
+		first, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		second, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		left = func(item Item) (types.AttributeValue, bool) {
+			firstValue, firstFound := first(item)
+			secondValue, secondFound := second(item)
+			firstList, firstOk := firstValue.(*types.AttributeValueMemberL)
+			secondList, secondOk := secondValue.(*types.AttributeValueMemberL)
+			if !firstFound || !secondFound || !firstOk || !secondOk {
+				return nil, false
+			}
+			return &types.AttributeValueMemberL{Value: slices.Concat(firstList.Value, secondList.Value)}, true
+		}
+	default:
+		if left, err = p.parseOperand(); err != nil {
+			return nil, err
+		}
+	}
+
+	if operator := p.peek().text; operator == "+" || operator == "-" {
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item Item) (types.AttributeValue, bool) {
+			leftValue, leftFound := left(item)
+			rightValue, rightFound := right(item)
+			leftNumber, leftOk := leftValue.(*types.AttributeValueMemberN)
+			rightNumber, rightOk := rightValue.(*types.AttributeValueMemberN)
+			if !leftFound || !rightFound || !leftOk || !rightOk {
+				return nil, false
+			}
+			a, _, errA := big.ParseFloat(leftNumber.Value, 10, 256, big.ToNearestEven)
+			b, _, errB := big.ParseFloat(rightNumber.Value, 10, 256, big.ToNearestEven)
+			if errA != nil || errB != nil {
+				return nil, false
+			}
+			if operator == "+" {
+				a.Add(a, b)
+			} else {
+				a.Sub(a, b)
+			}
+			return &types.AttributeValueMemberN{Value: a.Text('f', -1)}, true
+		}, nil
+	}
+	return left, nil
+}
+
+// compareAttributeValues orders two scalar values of the same type
+func compareAttributeValues(a, b types.AttributeValue) (int, bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			x, _, errX := big.ParseFloat(a.Value, 10, 256, big.ToNearestEven)
+			y, _, errY := big.ParseFloat(b.Value, 10, 256, big.ToNearestEven)
+			if errX != nil || errY != nil {
+				return 0, false
+			}
+			return x.Cmp(y), true
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value), true
+		}
+	}
+	return 0, false
+}
+
+func equalAttributeValues(a, b types.AttributeValue) bool {
+	if compared, ok := compareAttributeValues(a, b); ok {
+		return compared == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func attributeType(value types.AttributeValue) string {
+	switch value.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	}
+	return ""
+}
+
+func attributeSize(value types.AttributeValue) (int, bool) {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(value.Value), true
+	case *types.AttributeValueMemberB:
+		return len(value.Value), true
+	case *types.AttributeValueMemberSS:
+		return len(value.Value), true
+	case *types.AttributeValueMemberNS:
+		return len(value.Value), true
+	case *types.AttributeValueMemberBS:
+		return len(value.Value), true
+	case *types.AttributeValueMemberM:
+		return len(value.Value), true
+	case *types.AttributeValueMemberL:
+		return len(value.Value), true
+	}
+	return 0, false
+}
+
+// copyAttributeValue deep copies maps and lists so updates don't alias stored items
+func copyAttributeValue(value types.AttributeValue) types.AttributeValue {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(value.Value)}
+	case *types.AttributeValueMemberL:
+		list := make([]types.AttributeValue, len(value.Value))
+		for index, element := range value.Value {
+			list[index] = copyAttributeValue(element)
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	}
+	return value
+}
+
+func copyItem(item Item) Item {
+	if item == nil {
+		return nil
+	}
+	copied := make(Item, len(item))
+	for name, value := range item {
+		copied[name] = copyAttributeValue(value)
+	}
+	return copied
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestParseCondition(t *testing.T) {
+	item := Item{
+		"pk":     &types.AttributeValueMemberS{Value: "order#1"},
+		"total":  &types.AttributeValueMemberN{Value: "12.5"},
+		"tags":   &types.AttributeValueMemberSS{Value: []string{"new", "gift"}},
+		"lines":  &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "a"}}},
+		"status": &types.AttributeValueMemberS{Value: "open"},
+		"address": &types.AttributeValueMemberM{Value: Item{
+			"city": &types.AttributeValueMemberS{Value: "Oslo"},
+		}},
+	}
+	names := map[string]string{"#s": "status", "#a": "address"}
+	values := map[string]types.AttributeValue{
+		":open":  &types.AttributeValueMemberS{Value: "open"},
+		":order": &types.AttributeValueMemberS{Value: "order#"},
+		":ten":   &types.AttributeValueMemberN{Value: "10"},
+		":20":    &types.AttributeValueMemberN{Value: "20.0"},
+		":gift":  &types.AttributeValueMemberS{Value: "gift"},
+		":oslo":  &types.AttributeValueMemberS{Value: "Oslo"},
+		":one":   &types.AttributeValueMemberN{Value: "1"},
+		":ss":    &types.AttributeValueMemberS{Value: "SS"},
+	}
+	var tests = []struct {
+		expression string
+		want       bool
+	}{
+		{"#s = :open", true},
+		{"#s <> :open", false},
+		{"missing <> :open", true},
+		{"missing = :open", false},
+		{"total > :ten AND total < :20", true},
+		{"total BETWEEN :ten AND :20", true},
+		{"total < :ten OR #s = :open", true},
+		{"NOT (total < :ten OR #s = :open)", false},
+		{"begins_with(pk, :order)", true},
+		{"contains(tags, :gift)", true},
+		{"contains(pk, :gift)", false},
+		{"attribute_exists(#a.city) AND attribute_not_exists(#a.zip)", true},
+		{"#a.city = :oslo", true},
+		{"size(lines) = :one", true},
+		{"attribute_type(tags, :ss)", true},
+		{"#s IN (:gift, :open)", true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			c, err := parseCondition(test.expression, names, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c(item); got != test.want {
+				t.Errorf("got %t want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	item := Item{
+		"count": &types.AttributeValueMemberN{Value: "1"},
+		"eta":   &types.AttributeValueMemberS{Value: "soon"},
+	}
+	values := map[string]types.AttributeValue{
+		":one":  &types.AttributeValueMemberN{Value: "1"},
+		":done": &types.AttributeValueMemberS{Value: "done"},
+	}
+	err := applyUpdate(item, "SET #c = #c + :one, #s = if_not_exists(#s, :done)\nREMOVE eta\n", map[string]string{"#c": "count", "#s": "status"}, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := item["count"].(*types.AttributeValueMemberN).Value; count != "2" {
+		t.Errorf("got count %s want 2", count)
+	}
+	if status := item["status"].(*types.AttributeValueMemberS).Value; status != "done" {
+		t.Errorf("got status %s want done", status)
+	}
+	if _, found := item["eta"]; found {
+		t.Errorf("got eta want it removed")
+	}
+}
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// fakeShardId is the single shard of every fake stream, it is never closed
+const fakeShardId = "shardId-00000000000000000000-00000001"
+
+var _ StreamsAPI = (*FakeDynamodb)(nil)
+
+// enableStream sets the stream arn of a table whose stream is enabled
+func (table *fakeTable) enableStream() {
+	specification := table.description.StreamSpecification
+	if specification == nil || !aws.ToBool(specification.StreamEnabled) {
+		return
+	}
+	if table.description.LatestStreamArn == nil {
+		label := strconv.FormatInt(time.Now().UnixNano(), 10)
+		table.description.LatestStreamLabel = &label
+		table.description.LatestStreamArn = aws.String(*table.description.TableArn + "/stream/" + label)
+	}
+}
+
+// write replaces or, when item is nil, deletes the item with the given key,
+// recording the change when the stream of the table is enabled
+func (table *fakeTable) write(key string, item Item) {
+	existing, found := table.items[key]
+	if item == nil {
+		delete(table.items, key)
+	} else {
+		table.items[key] = item
+	}
+	specification := table.description.StreamSpecification
+	if specification == nil || !aws.ToBool(specification.StreamEnabled) || (!found && item == nil) {
+		return
+	}
+
+	record := streamstypes.Record{
+		EventID:     aws.String(strconv.Itoa(len(table.records) + 1)),
+		EventSource: aws.String("aws:dynamodb"),
+		Dynamodb: &streamstypes.StreamRecord{
+			ApproximateCreationDateTime: aws.Time(time.Now()),
+			SequenceNumber:              aws.String(fmt.Sprintf("%021d", len(table.records)+1)),
+			StreamViewType:              streamstypes.StreamViewType(specification.StreamViewType),
+		},
+	}
+	switch {
+	case !found:
+		record.EventName = streamstypes.OperationTypeInsert
+	case item == nil:
+		record.EventName = streamstypes.OperationTypeRemove
+	default:
+		record.EventName = streamstypes.OperationTypeModify
+	}
+	keyItem := item
+	if keyItem == nil {
+		keyItem = existing
+	}
+	keys := make(Item)
+	for _, element := range table.description.KeySchema {
+		keys[*element.AttributeName] = keyItem[*element.AttributeName]
+	}
+	record.Dynamodb.Keys = toStreamsItem(keys)
+	viewType := specification.StreamViewType
+	if found && (viewType == types.StreamViewTypeOldImage || viewType == types.StreamViewTypeNewAndOldImages) {
+		record.Dynamodb.OldImage = toStreamsItem(existing)
+	}
+	if item != nil && (viewType == types.StreamViewTypeNewImage || viewType == types.StreamViewTypeNewAndOldImages) {
+		record.Dynamodb.NewImage = toStreamsItem(item)
+	}
+	table.records = append(table.records, record)
+}
+
+func (f *FakeDynamodb) streamTable(streamArn *string) (*fakeTable, error) {
+	for _, table := range f.tables {
+		if aws.ToString(table.description.LatestStreamArn) == aws.ToString(streamArn) {
+			return table, nil
+		}
+	}
+	return nil, &streamstypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Requested resource not found: Stream: %s not found", aws.ToString(streamArn)))}
+}
+
+func (f *FakeDynamodb) DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.streamTable(params.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: &streamstypes.StreamDescription{
+		StreamArn:      params.StreamArn,
+		StreamStatus:   streamstypes.StreamStatusEnabled,
+		StreamViewType: streamstypes.StreamViewType(table.description.StreamSpecification.StreamViewType),
+		TableName:      table.description.TableName,
+		KeySchema:      streamsKeySchema(table.description.KeySchema),
+		Shards: []streamstypes.Shard{{
+			ShardId:             aws.String(fakeShardId),
+			SequenceNumberRange: &streamstypes.SequenceNumberRange{StartingSequenceNumber: aws.String(fmt.Sprintf("%021d", 1))},
+		}},
+	}}, nil
+}
+
+// GetShardIterator returns the stream arn and the position of the next record as iterator
+func (f *FakeDynamodb) GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	table, err := f.streamTable(params.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToString(params.ShardId) != fakeShardId {
+		return nil, &streamstypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Requested resource not found: Shard: %s not found", aws.ToString(params.ShardId)))}
+	}
+	var position int
+	switch params.ShardIteratorType {
+	case streamstypes.ShardIteratorTypeTrimHorizon:
+		position = 0
+	case streamstypes.ShardIteratorTypeLatest:
+		position = len(table.records)
+	case streamstypes.ShardIteratorTypeAtSequenceNumber, streamstypes.ShardIteratorTypeAfterSequenceNumber:
+		sequenceNumber, err := strconv.Atoi(aws.ToString(params.SequenceNumber))
+		if err != nil || sequenceNumber < 1 || sequenceNumber > len(table.records) {
+			return nil, fakeValidationError("Invalid SequenceNumber: %s", aws.ToString(params.SequenceNumber))
+		}
+		position = sequenceNumber - 1
+		if params.ShardIteratorType == streamstypes.ShardIteratorTypeAfterSequenceNumber {
+			position++
+		}
+	default:
+		return nil, fakeValidationError("Invalid ShardIteratorType: %s", params.ShardIteratorType)
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: fakeIterator(*params.StreamArn, position)}, nil
+}
+
+func (f *FakeDynamodb) GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	streamArn, positionText, _ := strings.Cut(aws.ToString(params.ShardIterator), "|")
+	position, err := strconv.Atoi(positionText)
+	if err != nil {
+		return nil, fakeValidationError("Invalid ShardIterator: %s", aws.ToString(params.ShardIterator))
+	}
+	table, err := f.streamTable(&streamArn)
+	if err != nil {
+		return nil, err
+	}
+	end := len(table.records)
+	if params.Limit != nil {
+		end = min(end, position+int(*params.Limit))
+	}
+	return &dynamodbstreams.GetRecordsOutput{
+		Records:           table.records[position:end],
+		NextShardIterator: fakeIterator(streamArn, end),
+	}, nil
+}
+
+func fakeIterator(streamArn string, position int) *string {
+	return aws.String(streamArn + "|" + strconv.Itoa(position))
+}
+
+func streamsKeySchema(keySchema []types.KeySchemaElement) []streamstypes.KeySchemaElement {
+	var elements []streamstypes.KeySchemaElement
+	for _, element := range keySchema {
+		elements = append(elements, streamstypes.KeySchemaElement{AttributeName: element.AttributeName, KeyType: streamstypes.KeyType(element.KeyType)})
+	}
+	return elements
+}
+
+func toStreamsItem(item Item) map[string]streamstypes.AttributeValue {
+	streamsItem := make(map[string]streamstypes.AttributeValue, len(item))
+	for name, value := range item {
+		streamsItem[name] = toStreamsValue(value)
+	}
+	return streamsItem
+}
+
+// toStreamsValue converts an attribute value to the identical type of the streams client
+func toStreamsValue(value types.AttributeValue) streamstypes.AttributeValue {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return &streamstypes.AttributeValueMemberS{Value: value.Value}
+	case *types.AttributeValueMemberN:
+		return &streamstypes.AttributeValueMemberN{Value: value.Value}
+	case *types.AttributeValueMemberB:
+		return &streamstypes.AttributeValueMemberB{Value: value.Value}
+	case *types.AttributeValueMemberBOOL:
+		return &streamstypes.AttributeValueMemberBOOL{Value: value.Value}
+	case *types.AttributeValueMemberNULL:
+		return &streamstypes.AttributeValueMemberNULL{Value: value.Value}
+	case *types.AttributeValueMemberSS:
+		return &streamstypes.AttributeValueMemberSS{Value: value.Value}
+	case *types.AttributeValueMemberNS:
+		return &streamstypes.AttributeValueMemberNS{Value: value.Value}
+	case *types.AttributeValueMemberBS:
+		return &streamstypes.AttributeValueMemberBS{Value: value.Value}
+	case *types.AttributeValueMemberL:
+		list := make([]streamstypes.AttributeValue, len(value.Value))
+		for i, element := range value.Value {
+			list[i] = toStreamsValue(element)
+		}
+		return &streamstypes.AttributeValueMemberL{Value: list}
+	case *types.AttributeValueMemberM:
+		return &streamstypes.AttributeValueMemberM{Value: toStreamsItem(value.Value)}
+	}
+	return nil
+}
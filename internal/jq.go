@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/itchyny/gojq"
+)
+
+// JqQuery is a compiled jq expression applied to unmarshalled items
+type JqQuery struct {
+	expression string
+	code       *gojq.Code
+}
+
+func CompileJq(expression string) (*JqQuery, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression %q [%w]", expression, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression %q [%w]", expression, err)
+	}
+	return &JqQuery{expression: expression, code: code}, nil
+}
+
+// Run applies the expression to a value and returns every result, halt
+// without an error value ends the results
+func (query *JqQuery) Run(value any) iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		input, err := JsonValue(value)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		results := query.code.Run(input)
+		for {
+			result, ok := results.Next()
+			if !ok {
+				return
+			}
+			if err, ok := result.(error); ok {
+				var haltError *gojq.HaltError
+				if errors.As(err, &haltError) && haltError.Value() == nil {
+					return
+				}
+				yield(nil, fmt.Errorf("jq expression %q failed [%w]", query.expression, err))
+				return
+			}
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}
+}
+
+// JsonValue converts a value to the types of decoded json, e.g. sets to arrays and
+// numbers to json.Number, by a round trip through json, as gojq and templates expect
+func JsonValue(value any) (any, error) {
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal %T as json [%w]", value, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(valueJson))
+	decoder.UseNumber()
+	var input any
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("failed to decode json [%w]", err)
+	}
+	return input, nil
+}
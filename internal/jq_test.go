@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestJqQuery(t *testing.T) {
+	item, err := UnmarshalItem(Item{
+		"id":    &types.AttributeValueMemberN{Value: "7"},
+		"tags":  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"price": &types.AttributeValueMemberN{Value: "2.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := CompileJq(`{id, tag: .tags[], double: (.price * 2)}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for result, err := range query.Run(item) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		resultJson, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(resultJson))
+	}
+	want := []string{`{"double":5,"id":7,"tag":"a"}`, `{"double":5,"id":7,"tag":"b"}`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := CompileJq(".["); err == nil {
+		t.Errorf("got no error for an invalid expression")
+	}
+	query, _ = CompileJq(`.id | ascii_downcase`)
+	for _, err := range query.Run(item) {
+		if err == nil {
+			t.Errorf("got no error applying a string function to a number")
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// capacityBucket is a token bucket of capacity units. The cost of a request is
+// only known from its response, so requests wait for the bucket to be out of
+// debt and their consumed capacity is charged afterwards.
+type capacityBucket struct {
+	mutex   sync.Mutex
+	rate    float64
+	tokens  float64
+	updated time.Time
+	now     func() time.Time
+}
+
+func newCapacityBucket(rate float64) *capacityBucket {
+	return &capacityBucket{rate: rate, tokens: rate, updated: time.Now(), now: time.Now}
+}
+
+func (b *capacityBucket) refill() {
+	now := b.now()
+	b.tokens = min(b.rate, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+// delay is how long until the bucket is out of debt
+func (b *capacityBucket) delay() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill()
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *capacityBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.delay()
+		if delay == 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (b *capacityBucket) consume(units float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill()
+	b.tokens -= units
+}
+
+// RateLimitedClient limits the read and write capacity units per second used
+// through the client it wraps, requests it doesn't limit are passed through
+type RateLimitedClient struct {
+	DynamodbAPI
+	reads  *capacityBucket
+	writes *capacityBucket
+}
+
+// NewRateLimitedClient limits reads and writes each to rate capacity units per second
+func NewRateLimitedClient(client DynamodbAPI, rate float64) *RateLimitedClient {
+	return &RateLimitedClient{
+		DynamodbAPI: client,
+		reads:       newCapacityBucket(rate),
+		writes:      newCapacityBucket(rate),
+	}
+}
+
+// charge takes the consumed capacity from the buckets, capacity that isn't
+// split in read and write units is charged to the bucket of fallback
+func (c *RateLimitedClient) charge(fallback *capacityBucket, consumed ...types.ConsumedCapacity) {
+	for _, capacity := range consumed {
+		if capacity.ReadCapacityUnits != nil || capacity.WriteCapacityUnits != nil {
+			if capacity.ReadCapacityUnits != nil {
+				c.reads.consume(*capacity.ReadCapacityUnits)
+			}
+			if capacity.WriteCapacityUnits != nil {
+				c.writes.consume(*capacity.WriteCapacityUnits)
+			}
+		} else if capacity.CapacityUnits != nil {
+			fallback.consume(*capacity.CapacityUnits)
+		}
+	}
+}
+
+// consumedCapacity asks for the consumed capacity unless the caller already did
+func consumedCapacity(returnConsumedCapacity types.ReturnConsumedCapacity) types.ReturnConsumedCapacity {
+	if returnConsumedCapacity == "" || returnConsumedCapacity == types.ReturnConsumedCapacityNone {
+		return types.ReturnConsumedCapacityTotal
+	}
+	return returnConsumedCapacity
+}
+
+func (c *RateLimitedClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := c.reads.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.GetItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(c.reads, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := c.writes.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.PutItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(c.writes, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := c.writes.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.DeleteItem(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(c.writes, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := c.reads.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.Query(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(c.reads, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := c.reads.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.Scan(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(c.reads, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := c.writes.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.TransactWriteItems(ctx, &input, optFns...)
+	if err == nil {
+		c.charge(c.writes, output.ConsumedCapacity...)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	bucket := c.writes
+	if params.Statement != nil && isSelect(*params.Statement) {
+		bucket = c.reads
+	}
+	if err := bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.ExecuteStatement(ctx, &input, optFns...)
+	if err == nil && output.ConsumedCapacity != nil {
+		c.charge(bucket, *output.ConsumedCapacity)
+	}
+	return output, err
+}
+
+func (c *RateLimitedClient) BatchExecuteStatement(ctx context.Context, params *dynamodb.BatchExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	bucket := c.reads
+	for _, statement := range params.Statements {
+		if statement.Statement != nil && !isSelect(*statement.Statement) {
+			bucket = c.writes
+		}
+	}
+	if err := bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+	input := *params
+	input.ReturnConsumedCapacity = consumedCapacity(params.ReturnConsumedCapacity)
+	output, err := c.DynamodbAPI.BatchExecuteStatement(ctx, &input, optFns...)
+	if err == nil {
+		c.charge(bucket, output.ConsumedCapacity...)
+	}
+	return output, err
+}
+
+func isSelect(statement string) bool {
+	fields := strings.Fields(statement)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "select")
+}
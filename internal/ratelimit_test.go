@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCapacityBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newCapacityBucket(10)
+	bucket.now = func() time.Time { return now }
+	bucket.updated = now
+
+	if delay := bucket.delay(); delay != 0 {
+		t.Errorf("got delay %v for a full bucket", delay)
+	}
+	bucket.consume(25)
+	if delay := bucket.delay(); delay != 1500*time.Millisecond {
+		t.Errorf("got delay %v, want 1.5s to pay back 15 units at 10/s", delay)
+	}
+	now = now.Add(time.Second)
+	if delay := bucket.delay(); delay != 500*time.Millisecond {
+		t.Errorf("got delay %v after a second, want 0.5s", delay)
+	}
+	now = now.Add(time.Minute)
+	if bucket.delay(); bucket.tokens != 10 {
+		t.Errorf("got %v tokens, want the bucket capped at its rate", bucket.tokens)
+	}
+}
+
+func TestRateLimitedClientCharge(t *testing.T) {
+	client := NewRateLimitedClient(nil, 100)
+	client.charge(client.reads,
+		types.ConsumedCapacity{CapacityUnits: aws.Float64(30)},
+		types.ConsumedCapacity{CapacityUnits: aws.Float64(5), ReadCapacityUnits: aws.Float64(2), WriteCapacityUnits: aws.Float64(3)},
+	)
+	if reads := client.reads.tokens; reads < 67.9 || reads > 68.1 {
+		t.Errorf("got %v read tokens, want 68", reads)
+	}
+	if writes := client.writes.tokens; writes < 96.9 || writes > 97.1 {
+		t.Errorf("got %v write tokens, want 97", writes)
+	}
+}
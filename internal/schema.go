@@ -0,0 +1,323 @@
+package internal
+
+import (
+	"encoding/base64"
+	"iter"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MaxExamples is how many distinct example values are kept per attribute
+const MaxExamples = 3
+
+// AttributeSchema is what was observed of an attribute, a map entry or the elements of a list
+type AttributeSchema struct {
+	Count int
+	// Types counts the occurrences by dynamodb type, S, N, B, BOOL, NULL, SS, NS, BS, L or M
+	Types    map[string]int
+	Examples []string                    // distinct scalar values
+	Decimal  bool                        // some numbers have a fraction or exponent
+	Fields   map[string]*AttributeSchema // of the maps
+	Elements *AttributeSchema            // of the lists
+}
+
+// EntitySchema is the schema of the items sharing a prefix of the group by attribute
+type EntitySchema struct {
+	Prefix     string
+	Items      int
+	Attributes map[string]*AttributeSchema
+}
+
+// Schema is inferred from items, grouped into entities for single table designs
+type Schema struct {
+	// GroupBy is the attribute, typically the sort key, whose value up to the
+	// separator names the entity of an item, empty for a single entity
+	GroupBy   string
+	Separator string
+	Entities  []*EntitySchema // by prefix
+}
+
+func NewSchema(groupBy, separator string) *Schema {
+	return &Schema{GroupBy: groupBy, Separator: separator}
+}
+
+// InferSchema adds every item to a new schema
+func InferSchema(items iter.Seq2[Item, error], groupBy, separator string) (*Schema, error) {
+	schema := NewSchema(groupBy, separator)
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		schema.Add(item)
+	}
+	return schema, nil
+}
+
+// EntityPrefix returns the part of a string value before the separator, empty
+// for other values or when there is no separator
+func EntityPrefix(value types.AttributeValue, separator string) string {
+	if text, ok := value.(*types.AttributeValueMemberS); ok && separator != "" {
+		if prefix, _, found := strings.Cut(text.Value, separator); found {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func (schema *Schema) Add(item Item) {
+	prefix := ""
+	if schema.GroupBy != "" {
+		prefix = EntityPrefix(item[schema.GroupBy], schema.Separator)
+	}
+	index, found := slices.BinarySearchFunc(schema.Entities, prefix, func(entity *EntitySchema, prefix string) int {
+		return strings.Compare(entity.Prefix, prefix)
+	})
+	if !found {
+		schema.Entities = slices.Insert(schema.Entities, index, &EntitySchema{Prefix: prefix, Attributes: make(map[string]*AttributeSchema)})
+	}
+	entity := schema.Entities[index]
+	entity.Items++
+	addFields(entity.Attributes, item)
+}
+
+func addFields(fields map[string]*AttributeSchema, item Item) {
+	for name, value := range item {
+		field, found := fields[name]
+		if !found {
+			field = &AttributeSchema{Types: make(map[string]int)}
+			fields[name] = field
+		}
+		field.add(value)
+	}
+}
+
+func (attribute *AttributeSchema) add(value types.AttributeValue) {
+	attribute.Count++
+	attribute.Types[TypeName(value)]++
+	switch value := value.(type) {
+	case *types.AttributeValueMemberM:
+		if attribute.Fields == nil {
+			attribute.Fields = make(map[string]*AttributeSchema)
+		}
+		addFields(attribute.Fields, value.Value)
+	case *types.AttributeValueMemberNS:
+		for _, number := range value.Value {
+			attribute.Decimal = attribute.Decimal || isDecimal(number)
+		}
+	case *types.AttributeValueMemberL:
+		if attribute.Elements == nil {
+			attribute.Elements = &AttributeSchema{Types: make(map[string]int)}
+		}
+		for _, element := range value.Value {
+			attribute.Elements.add(element)
+		}
+	default:
+		if number, ok := value.(*types.AttributeValueMemberN); ok {
+			attribute.Decimal = attribute.Decimal || isDecimal(number.Value)
+		}
+		if example, ok := exampleValue(value); ok && len(attribute.Examples) < MaxExamples && !slices.Contains(attribute.Examples, example) {
+			attribute.Examples = append(attribute.Examples, example)
+		}
+	}
+}
+
+func isDecimal(number string) bool {
+	return strings.ContainsAny(number, ".eE")
+}
+
+// TypeName returns the dynamodb type of a value, e.g. S or NS
+func TypeName(value types.AttributeValue) string {
+	switch value.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+	return "?"
+}
+
+// exampleValue renders a scalar value, shortened to 40 characters
+func exampleValue(value types.AttributeValue) (string, bool) {
+	var example string
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		example = value.Value
+	case *types.AttributeValueMemberN:
+		example = value.Value
+	case *types.AttributeValueMemberB:
+		example = base64.StdEncoding.EncodeToString(value.Value)
+	case *types.AttributeValueMemberBOOL:
+		example = "false"
+		if value.Value {
+			example = "true"
+		}
+	default:
+		return "", false
+	}
+	if runes := []rune(example); len(runes) > 40 {
+		example = string(runes[:39]) + "…"
+	}
+	return example, true
+}
+
+// TypeNames returns the observed types, most frequent first
+func (attribute *AttributeSchema) TypeNames() []string {
+	names := slices.Sorted(maps.Keys(attribute.Types))
+	slices.SortStableFunc(names, func(a, b string) int {
+		return attribute.Types[b] - attribute.Types[a]
+	})
+	return names
+}
+
+// AttributePath is an attribute of an entity with its path, e.g. address.city or lines[].sku
+type AttributePath struct {
+	Path string
+	// Parent counts the items, maps or list elements that could hold the attribute
+	Parent int
+	*AttributeSchema
+}
+
+// Paths lists every attribute of the entity and the attributes nested in its maps and lists, by path
+func (entity *EntitySchema) Paths() []AttributePath {
+	var paths []AttributePath
+	var walk func(prefix string, parent int, fields map[string]*AttributeSchema)
+	var walkAttribute func(path string, parent int, attribute *AttributeSchema)
+	walk = func(prefix string, parent int, fields map[string]*AttributeSchema) {
+		for _, name := range slices.Sorted(maps.Keys(fields)) {
+			walkAttribute(prefix+name, parent, fields[name])
+		}
+	}
+	walkAttribute = func(path string, parent int, attribute *AttributeSchema) {
+		paths = append(paths, AttributePath{Path: path, Parent: parent, AttributeSchema: attribute})
+		if attribute.Fields != nil {
+			walk(path+".", attribute.Types["M"], attribute.Fields)
+		}
+		if attribute.Elements != nil {
+			walkAttribute(path+"[]", attribute.Elements.Count, attribute.Elements)
+		}
+	}
+	walk("", entity.Items, entity.Attributes)
+	return paths
+}
+
+// JsonSchema returns a JSON Schema of the items, with one definition per
+// entity when the items are grouped into several
+func (schema *Schema) JsonSchema(title string) map[string]any {
+	document := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+	}
+	switch len(schema.Entities) {
+	case 0:
+		document["type"] = "object"
+		document["title"] = title
+		return document
+	case 1:
+		maps.Copy(document, schema.Entities[0].JsonSchema(schema.GroupBy, schema.Separator))
+		document["title"] = title
+		return document
+	}
+	document["title"] = title
+	definitions := make(map[string]any)
+	var references []any
+	for _, entity := range schema.Entities {
+		name := entity.Prefix
+		if name == "" {
+			name = "other"
+		}
+		definitions[name] = entity.JsonSchema(schema.GroupBy, schema.Separator)
+		references = append(references, map[string]any{"$ref": "#/$defs/" + name})
+	}
+	document["$defs"] = definitions
+	document["oneOf"] = references
+	return document
+}
+
+// JsonSchema returns the JSON Schema of the items of the entity, attributes
+// present in every item are required
+func (entity *EntitySchema) JsonSchema(groupBy, separator string) map[string]any {
+	document := objectSchema(entity.Items, entity.Attributes)
+	if entity.Prefix != "" {
+		document["title"] = entity.Prefix
+		// the prefix tells the entities apart
+		property := document["properties"].(map[string]any)[groupBy].(map[string]any)
+		property["pattern"] = "^" + regexp.QuoteMeta(entity.Prefix+separator)
+	}
+	return document
+}
+
+func objectSchema(count int, fields map[string]*AttributeSchema) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		properties[name] = fields[name].JsonSchema()
+		if fields[name].Count >= count {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+// JsonSchema returns the JSON Schema of the values of the attribute, any of
+// the observed types when there are several
+func (attribute *AttributeSchema) JsonSchema() map[string]any {
+	var schemas []any
+	for _, name := range attribute.TypeNames() {
+		var schema map[string]any
+		switch name {
+		case "S":
+			schema = map[string]any{"type": "string"}
+		case "N":
+			schema = map[string]any{"type": "number"}
+		case "B":
+			schema = map[string]any{"type": "string", "contentEncoding": "base64"}
+		case "BOOL":
+			schema = map[string]any{"type": "boolean"}
+		case "NULL":
+			schema = map[string]any{"type": "null"}
+		case "SS":
+			schema = map[string]any{"type": "array", "uniqueItems": true, "items": map[string]any{"type": "string"}}
+		case "NS":
+			schema = map[string]any{"type": "array", "uniqueItems": true, "items": map[string]any{"type": "number"}}
+		case "BS":
+			schema = map[string]any{"type": "array", "uniqueItems": true, "items": map[string]any{"type": "string", "contentEncoding": "base64"}}
+		case "L":
+			schema = map[string]any{"type": "array"}
+			if attribute.Elements != nil {
+				schema["items"] = attribute.Elements.JsonSchema()
+			}
+		case "M":
+			schema = objectSchema(attribute.Types["M"], attribute.Fields)
+		default:
+			continue
+		}
+		schemas = append(schemas, schema)
+	}
+	if len(schemas) == 1 {
+		schema := schemas[0].(map[string]any)
+		if schema["type"] == "string" && len(attribute.Examples) > 0 {
+			schema["examples"] = attribute.Examples
+		}
+		return schema
+	}
+	return map[string]any{"anyOf": schemas}
+}
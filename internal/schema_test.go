@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	schema := NewSchema("sk", "#")
+	for _, values := range []map[string]any{
+		{"pk": "CUSTOMER#1", "sk": "PROFILE#1", "name": "Ann", "address": map[string]any{"city": "Oslo"}},
+		{"pk": "CUSTOMER#1", "sk": "ORDER#1", "total": 5, "lines": []any{map[string]any{"sku": "a"}}},
+		{"pk": "CUSTOMER#1", "sk": "ORDER#2", "total": "unknown", "note": "late"},
+	} {
+		item, err := MarshalItem(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema.Add(item)
+	}
+	if len(schema.Entities) != 2 || schema.Entities[0].Prefix != "ORDER" || schema.Entities[0].Items != 2 {
+		t.Fatalf("got entities %+v, want ORDER and PROFILE", schema.Entities)
+	}
+
+	var paths []string
+	for _, path := range schema.Entities[0].Paths() {
+		paths = append(paths, path.Path)
+	}
+	if want := []string{"lines", "lines[]", "lines[].sku", "note", "pk", "sk", "total"}; !slices.Equal(paths, want) {
+		t.Errorf("got paths %v want %v", paths, want)
+	}
+	total := schema.Entities[0].Attributes["total"]
+	if types := total.TypeNames(); len(types) != 2 || total.Count != 2 {
+		t.Errorf("got total types %v seen %d times, want N and S twice", types, total.Count)
+	}
+
+	document, err := json.Marshal(schema.JsonSchema("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jsonSchema struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]any
+			Required   []string
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(document, &jsonSchema); err != nil {
+		t.Fatal(err)
+	}
+	order := jsonSchema.Defs["ORDER"]
+	if !slices.Equal(order.Required, []string{"pk", "sk", "total"}) || order.Properties["sk"]["pattern"] != "^ORDER#" {
+		t.Errorf("got ORDER schema %+v", order)
+	}
+	if _, found := order.Properties["total"]["anyOf"]; !found {
+		t.Errorf("got total %v, want any of number and string", order.Properties["total"])
+	}
+}
@@ -0,0 +1,314 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// StreamsAPI is the part of the dynamodb streams client used by ddb,
+// implemented by *dynamodbstreams.Client and by FakeDynamodb for tests
+type StreamsAPI interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+func StreamsClient(ctx context.Context, options ClientOptions) (*dynamodbstreams.Client, error) {
+	config, err := AwsConfig(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodbstreams.NewFromConfig(config, func(o *dynamodbstreams.Options) {
+		if options.EndpointUrl != "" {
+			o.BaseEndpoint = aws.String(options.EndpointUrl)
+		}
+	}), nil
+}
+
+// StreamArn returns the arn of the current stream of a table
+func StreamArn(ctx context.Context, client DynamodbAPI, tableName string) (string, error) {
+	description, err := DescribeTable(ctx, client, tableName)
+	if err != nil {
+		return "", err
+	}
+	specification := description.StreamSpecification
+	if specification == nil || !aws.ToBool(specification.StreamEnabled) || description.LatestStreamArn == nil {
+		return "", fmt.Errorf("table %s has no stream, enable it with a StreamSpecification", tableName)
+	}
+	return *description.LatestStreamArn, nil
+}
+
+// ChangeRecord is a stream record with the keys and images as items
+type ChangeRecord struct {
+	ShardId        string
+	SequenceNumber string
+	EventName      streamstypes.OperationType // INSERT, MODIFY or REMOVE
+	Time           time.Time
+	Keys           Item
+	OldImage       Item
+	NewImage       Item
+}
+
+// Checkpoint is how far a stream has been read, it is updated as records are consumed
+type Checkpoint struct {
+	// Shards holds the sequence number of the last consumed record per shard
+	Shards map[string]string `json:"shards"`
+	// Closed lists the shards consumed to their end
+	Closed []string `json:"closed"`
+}
+
+type StreamOptions struct {
+	// From is where shards without a checkpoint start, TRIM_HORIZON or LATEST
+	From streamstypes.ShardIteratorType
+	// Checkpoint, when set, resumes reading. It is updated before each record is
+	// yielded, so it can be saved once the record is processed.
+	Checkpoint *Checkpoint
+	// Follow keeps polling open shards for new records and the stream for new
+	// shards, otherwise reading stops once emptyPollLimit polls in a row of every
+	// open shard return no records, as a page can be empty before existing records
+	Follow bool
+	// PollInterval is the pause when following after a poll of every open shard
+	// returns no records
+	PollInterval time.Duration
+}
+
+// emptyPollLimit is the number of polls in a row without records ending the
+// reading of a stream which is not followed
+const emptyPollLimit = 3
+
+// shardReader is an open shard being read
+type shardReader struct {
+	shard    streamstypes.Shard
+	iterator *string
+}
+
+// ReadStream yields the records of all shards of a stream. Children of a shard
+// are read once it is consumed to its end, so records of an item stay in order.
+// A failed request ends the iteration with a *RequestError.
+func ReadStream(ctx context.Context, client StreamsAPI, streamArn string, options StreamOptions) iter.Seq2[ChangeRecord, error] {
+	return func(yield func(ChangeRecord, error) bool) {
+		checkpoint := options.Checkpoint
+		if checkpoint == nil {
+			checkpoint = &Checkpoint{}
+		}
+		if checkpoint.Shards == nil {
+			checkpoint.Shards = make(map[string]string)
+		}
+		// skipped holds shards closed before reading from LATEST started
+		skipped := make(map[string]bool)
+		skipClosed := options.From == streamstypes.ShardIteratorTypeLatest && len(checkpoint.Shards) == 0 && len(checkpoint.Closed) == 0
+		started := make(map[string]bool)
+		var readers []*shardReader
+		discover := true
+		emptyPolls := 0
+		for {
+			if discover {
+				discover = false
+				shards, err := describeShards(ctx, client, streamArn)
+				if err != nil {
+					yield(ChangeRecord{}, err)
+					return
+				}
+				if skipClosed {
+					skipClosed = false
+					for _, shard := range shards {
+						if shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil {
+							skipped[*shard.ShardId] = true
+						}
+					}
+				}
+				for _, shard := range readyShards(shards, checkpoint, started, skipped) {
+					started[*shard.ShardId] = true
+					iterator, err := shardIterator(ctx, client, streamArn, shard, checkpoint, options.From)
+					if err != nil {
+						yield(ChangeRecord{}, err)
+						return
+					}
+					readers = append(readers, &shardReader{shard: shard, iterator: iterator})
+				}
+				pruneClosed(checkpoint, shards)
+			}
+			if len(readers) == 0 {
+				if !options.Follow {
+					return
+				}
+				// children of a closed shard may not be listed yet
+				if err := sleep(ctx, options.PollInterval); err != nil {
+					yield(ChangeRecord{}, err)
+					return
+				}
+				discover = true
+				continue
+			}
+
+			idle := true
+			for _, reader := range readers {
+				shardId := *reader.shard.ShardId
+				output, err := client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{ShardIterator: reader.iterator})
+				var expired *streamstypes.ExpiredIteratorException
+				if errors.As(err, &expired) {
+					// iterators expire after 15 minutes, continue after the last consumed
+					// record or, without one, where the shard started so reading from
+					// LATEST does not replay older changes
+					if reader.iterator, err = shardIterator(ctx, client, streamArn, reader.shard, checkpoint, options.From); err != nil {
+						yield(ChangeRecord{}, err)
+						return
+					}
+					// the shard is not polled yet, reading does not stop
+					idle = false
+					continue
+				}
+				if err != nil {
+					yield(ChangeRecord{}, newRequestError("GetRecords", err))
+					return
+				}
+				for _, record := range output.Records {
+					idle = false
+					changeRecord, err := convertRecord(shardId, record)
+					if err != nil {
+						yield(ChangeRecord{}, err)
+						return
+					}
+					checkpoint.Shards[shardId] = changeRecord.SequenceNumber
+					if !yield(changeRecord, nil) {
+						return
+					}
+				}
+				reader.iterator = output.NextShardIterator
+				if reader.iterator == nil {
+					// the shard is closed and consumed, its children can be read
+					checkpoint.Closed = append(checkpoint.Closed, shardId)
+					delete(checkpoint.Shards, shardId)
+					discover = true
+				}
+			}
+			readers = slices.DeleteFunc(readers, func(reader *shardReader) bool {
+				return reader.iterator == nil
+			})
+			if !idle || discover {
+				emptyPolls = 0
+				continue
+			}
+			if emptyPolls++; !options.Follow {
+				if emptyPolls >= emptyPollLimit {
+					return
+				}
+				continue
+			}
+			if err := sleep(ctx, options.PollInterval); err != nil {
+				yield(ChangeRecord{}, err)
+				return
+			}
+		}
+	}
+}
+
+func describeShards(ctx context.Context, client StreamsAPI, streamArn string) ([]streamstypes.Shard, error) {
+	var shards []streamstypes.Shard
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: &streamArn}
+	for {
+		output, err := client.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, newRequestError("DescribeStream", err)
+		}
+		shards = append(shards, output.StreamDescription.Shards...)
+		if output.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		input.ExclusiveStartShardId = output.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// readyShards returns the shards to start reading: the ones not started,
+// consumed or skipped whose parent is consumed, skipped or no longer in the stream
+func readyShards(shards []streamstypes.Shard, checkpoint *Checkpoint, started, skipped map[string]bool) []streamstypes.Shard {
+	known := make(map[string]bool)
+	for _, shard := range shards {
+		known[*shard.ShardId] = true
+	}
+	done := func(shardId string) bool {
+		return skipped[shardId] || slices.Contains(checkpoint.Closed, shardId)
+	}
+	var ready []streamstypes.Shard
+	for _, shard := range shards {
+		shardId := *shard.ShardId
+		if started[shardId] || done(shardId) {
+			continue
+		}
+		if parent := aws.ToString(shard.ParentShardId); parent != "" && known[parent] && !done(parent) {
+			continue
+		}
+		ready = append(ready, shard)
+	}
+	return ready
+}
+
+// pruneClosed forgets consumed shards no longer in the stream, trimmed after
+// 24 hours, so the checkpoint does not grow with every split
+func pruneClosed(checkpoint *Checkpoint, shards []streamstypes.Shard) {
+	checkpoint.Closed = slices.DeleteFunc(checkpoint.Closed, func(shardId string) bool {
+		return !slices.ContainsFunc(shards, func(shard streamstypes.Shard) bool {
+			return aws.ToString(shard.ShardId) == shardId
+		})
+	})
+}
+
+func shardIterator(ctx context.Context, client StreamsAPI, streamArn string, shard streamstypes.Shard, checkpoint *Checkpoint, from streamstypes.ShardIteratorType) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         &streamArn,
+		ShardId:           shard.ShardId,
+		ShardIteratorType: from,
+	}
+	if sequenceNumber, found := checkpoint.Shards[*shard.ShardId]; found {
+		input.ShardIteratorType = streamstypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = &sequenceNumber
+	} else if shard.ParentShardId != nil && slices.Contains(checkpoint.Closed, *shard.ParentShardId) {
+		// children of consumed shards are read from their start
+		input.ShardIteratorType = streamstypes.ShardIteratorTypeTrimHorizon
+	}
+	output, err := client.GetShardIterator(ctx, input)
+	if err != nil {
+		var trimmed *streamstypes.TrimmedDataAccessException
+		if errors.As(err, &trimmed) {
+			return nil, fmt.Errorf("records of shard %s after the checkpoint are past the 24 hour retention [%w]", *shard.ShardId, err)
+		}
+		return nil, newRequestError("GetShardIterator", err)
+	}
+	return output.ShardIterator, nil
+}
+
+func convertRecord(shardId string, record streamstypes.Record) (ChangeRecord, error) {
+	changeRecord := ChangeRecord{ShardId: shardId, EventName: record.EventName}
+	streamRecord := record.Dynamodb
+	if streamRecord == nil {
+		return changeRecord, nil
+	}
+	changeRecord.SequenceNumber = aws.ToString(streamRecord.SequenceNumber)
+	changeRecord.Time = aws.ToTime(streamRecord.ApproximateCreationDateTime)
+	var err error
+	for _, image := range []struct {
+		from map[string]streamstypes.AttributeValue
+		to   *Item
+	}{
+		{streamRecord.Keys, &changeRecord.Keys},
+		{streamRecord.OldImage, &changeRecord.OldImage},
+		{streamRecord.NewImage, &changeRecord.NewImage},
+	} {
+		if image.from == nil {
+			continue
+		}
+		if *image.to, err = attributevalue.FromDynamoDBStreamsMap(image.from); err != nil {
+			return ChangeRecord{}, fmt.Errorf("failed to convert stream record %s [%w]", changeRecord.SequenceNumber, err)
+		}
+	}
+	return changeRecord, nil
+}
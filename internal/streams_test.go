@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+func TestReadyShards(t *testing.T) {
+	shard := func(shardId, parentShardId string, closed bool) streamstypes.Shard {
+		shard := streamstypes.Shard{ShardId: aws.String(shardId), SequenceNumberRange: &streamstypes.SequenceNumberRange{}}
+		if parentShardId != "" {
+			shard.ParentShardId = aws.String(parentShardId)
+		}
+		if closed {
+			shard.SequenceNumberRange.EndingSequenceNumber = aws.String("9")
+		}
+		return shard
+	}
+	// root was split into left and right, left into leftChild; expired is trimmed
+	shards := []streamstypes.Shard{
+		shard("root", "expired", true),
+		shard("left", "root", true),
+		shard("right", "root", false),
+		shard("leftChild", "left", false),
+	}
+	for _, test := range []struct {
+		name    string
+		closed  []string
+		skipped []string
+		want    []string
+	}{
+		{"from the start", nil, nil, []string{"root"}},
+		{"after a split", []string{"root"}, nil, []string{"left", "right"}},
+		{"after a split of a child", []string{"root", "left"}, nil, []string{"right", "leftChild"}},
+		{"from latest", nil, []string{"root", "left"}, []string{"right", "leftChild"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			skipped := make(map[string]bool)
+			for _, shardId := range test.skipped {
+				skipped[shardId] = true
+			}
+			var got []string
+			for _, shard := range readyShards(shards, &Checkpoint{Closed: test.closed}, map[string]bool{}, skipped) {
+				got = append(got, *shard.ShardId)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// testShard is a shard of testStreams with records numbered by their sequence number
+type testShard struct {
+	id, parent string
+	records    []int
+	closed     bool
+	// listedAfter is the number of DescribeStream calls not listing the shard yet
+	listedAfter int
+}
+
+// testStreams is a stream with several shards, unlike the single shard of
+// FakeDynamodb, whose iterator expires on a GetRecords call
+type testStreams struct {
+	shards    []*testShard
+	describes int
+	getCalls  int
+	// expireAt is the GetRecords call failing with an expired iterator, when
+	// record late is added to the first shard
+	expireAt, late int
+	// emptyCalls is the number of first GetRecords calls returning no records
+	emptyCalls int
+}
+
+func (s *testStreams) shard(shardId string) *testShard {
+	for _, shard := range s.shards {
+		if shard.id == shardId {
+			return shard
+		}
+	}
+	return nil
+}
+
+func (s *testStreams) DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	s.describes++
+	description := &streamstypes.StreamDescription{StreamArn: params.StreamArn}
+	for _, shard := range s.shards {
+		if s.describes <= shard.listedAfter {
+			continue
+		}
+		listed := streamstypes.Shard{ShardId: aws.String(shard.id), SequenceNumberRange: &streamstypes.SequenceNumberRange{}}
+		if shard.parent != "" {
+			listed.ParentShardId = aws.String(shard.parent)
+		}
+		if shard.closed {
+			listed.SequenceNumberRange.EndingSequenceNumber = aws.String(strconv.Itoa(slices.Max(shard.records)))
+		}
+		description.Shards = append(description.Shards, listed)
+	}
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: description}, nil
+}
+
+func (s *testStreams) GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	shard := s.shard(aws.ToString(params.ShardId))
+	if shard == nil {
+		return nil, &streamstypes.ResourceNotFoundException{Message: params.ShardId}
+	}
+	var position int
+	switch params.ShardIteratorType {
+	case streamstypes.ShardIteratorTypeTrimHorizon:
+		position = 0
+	case streamstypes.ShardIteratorTypeLatest:
+		position = len(shard.records)
+	case streamstypes.ShardIteratorTypeAfterSequenceNumber:
+		sequenceNumber, _ := strconv.Atoi(aws.ToString(params.SequenceNumber))
+		position = slices.Index(shard.records, sequenceNumber) + 1
+	default:
+		return nil, fmt.Errorf("unexpected ShardIteratorType %s", params.ShardIteratorType)
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(fmt.Sprintf("%s|%d", shard.id, position))}, nil
+}
+
+func (s *testStreams) GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	s.getCalls++
+	if s.getCalls == s.expireAt {
+		s.shards[0].records = append(s.shards[0].records, s.late)
+		return nil, &streamstypes.ExpiredIteratorException{}
+	}
+	shardId, positionText, _ := strings.Cut(aws.ToString(params.ShardIterator), "|")
+	shard := s.shard(shardId)
+	position, _ := strconv.Atoi(positionText)
+	output := &dynamodbstreams.GetRecordsOutput{}
+	if s.getCalls <= s.emptyCalls {
+		output.NextShardIterator = params.ShardIterator
+		return output, nil
+	}
+	for _, sequenceNumber := range shard.records[position:] {
+		output.Records = append(output.Records, streamstypes.Record{
+			EventName: streamstypes.OperationTypeInsert,
+			Dynamodb:  &streamstypes.StreamRecord{SequenceNumber: aws.String(strconv.Itoa(sequenceNumber))},
+		})
+	}
+	if !shard.closed {
+		output.NextShardIterator = aws.String(fmt.Sprintf("%s|%d", shard.id, len(shard.records)))
+	}
+	return output, nil
+}
+
+func TestReadStream(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		streams    *testStreams
+		options    StreamOptions
+		checkpoint Checkpoint
+		// want are the sequence numbers of the records read, reading stops
+		// after them when following
+		want           []string
+		wantCheckpoint Checkpoint
+	}{
+		{
+			name: "closed shard",
+			streams: &testStreams{shards: []*testShard{
+				{id: "parent", records: []int{1, 2}, closed: true},
+				{id: "child", parent: "parent", records: []int{3}},
+			}},
+			options:        StreamOptions{From: streamstypes.ShardIteratorTypeTrimHorizon},
+			want:           []string{"1", "2", "3"},
+			wantCheckpoint: Checkpoint{Shards: map[string]string{"child": "3"}, Closed: []string{"parent"}},
+		},
+		{
+			name: "child listed later",
+			streams: &testStreams{shards: []*testShard{
+				{id: "parent", records: []int{1}, closed: true},
+				{id: "child", parent: "parent", records: []int{2}, listedAfter: 3},
+			}},
+			options:        StreamOptions{From: streamstypes.ShardIteratorTypeTrimHorizon, Follow: true},
+			want:           []string{"1", "2"},
+			wantCheckpoint: Checkpoint{Shards: map[string]string{"child": "2"}, Closed: []string{"parent"}},
+		},
+		{
+			name: "trimmed shard",
+			streams: &testStreams{shards: []*testShard{
+				{id: "parent", records: []int{2}, closed: true},
+				{id: "child", parent: "parent", records: []int{3}},
+			}},
+			options:        StreamOptions{From: streamstypes.ShardIteratorTypeTrimHorizon},
+			checkpoint:     Checkpoint{Shards: map[string]string{}, Closed: []string{"trimmed", "parent"}},
+			want:           []string{"3"},
+			wantCheckpoint: Checkpoint{Shards: map[string]string{"child": "3"}, Closed: []string{"parent"}},
+		},
+		{
+			name:           "expired after a record",
+			streams:        &testStreams{shards: []*testShard{{id: "shard", records: []int{1}}}, expireAt: 2, late: 2},
+			options:        StreamOptions{From: streamstypes.ShardIteratorTypeTrimHorizon},
+			want:           []string{"1", "2"},
+			wantCheckpoint: Checkpoint{Shards: map[string]string{"shard": "2"}},
+		},
+		{
+			// without a consumed record the shard is read from LATEST again, not replayed
+			name:    "expired from latest",
+			streams: &testStreams{shards: []*testShard{{id: "shard", records: []int{1}}}, expireAt: 1, late: 2},
+			options: StreamOptions{From: streamstypes.ShardIteratorTypeLatest},
+		},
+		{
+			name:           "empty pages",
+			streams:        &testStreams{shards: []*testShard{{id: "shard", records: []int{1, 2}}}, emptyCalls: emptyPollLimit - 1},
+			options:        StreamOptions{From: streamstypes.ShardIteratorTypeTrimHorizon},
+			want:           []string{"1", "2"},
+			wantCheckpoint: Checkpoint{Shards: map[string]string{"shard": "2"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			checkpoint := &test.checkpoint
+			test.options.Checkpoint = checkpoint
+			var got []string
+			for record, err := range ReadStream(ctx, test.streams, "stream", test.options) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, record.SequenceNumber)
+				if test.options.Follow && len(got) == len(test.want) {
+					break
+				}
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got records %v want %v", got, test.want)
+			}
+			if !maps.Equal(checkpoint.Shards, test.wantCheckpoint.Shards) || !slices.Equal(checkpoint.Closed, test.wantCheckpoint.Closed) {
+				t.Errorf("got checkpoint %+v want %+v", *checkpoint, test.wantCheckpoint)
+			}
+		})
+	}
+}
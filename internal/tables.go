@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WaitInterval is how often the waiters describe the table
+var WaitInterval = 5 * time.Second
+
+func CreateTable(ctx context.Context, client DynamodbAPI, input *dynamodb.CreateTableInput) error {
+	if _, err := client.CreateTable(ctx, input); err != nil {
+		return newRequestError("CreateTable", err)
+	}
+	return nil
+}
+
+func UpdateTable(ctx context.Context, client DynamodbAPI, input *dynamodb.UpdateTableInput) error {
+	if _, err := client.UpdateTable(ctx, input); err != nil {
+		return newRequestError("UpdateTable", err)
+	}
+	return nil
+}
+
+func DeleteTable(ctx context.Context, client DynamodbAPI, tableName string) error {
+	if _, err := client.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: &tableName}); err != nil {
+		return newRequestError("DeleteTable", err)
+	}
+	return nil
+}
+
+// TimeToLiveAttribute returns the ttl attribute of a table, empty when ttl is disabled
+func TimeToLiveAttribute(ctx context.Context, client DynamodbAPI, tableName string) (string, types.TimeToLiveStatus, error) {
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: &tableName})
+	if err != nil {
+		return "", "", newRequestError("DescribeTimeToLive", err)
+	}
+	description := output.TimeToLiveDescription
+	if description == nil {
+		return "", types.TimeToLiveStatusDisabled, nil
+	}
+	return aws.ToString(description.AttributeName), description.TimeToLiveStatus, nil
+}
+
+func UpdateTimeToLive(ctx context.Context, client DynamodbAPI, tableName string, attributeName string, enabled bool) error {
+	_, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: &tableName,
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: &attributeName,
+			Enabled:       &enabled,
+		},
+	})
+	if err != nil {
+		return newRequestError("UpdateTimeToLive", err)
+	}
+	return nil
+}
+
+// WaitForActive polls until the table and all its global indexes are ACTIVE
+func WaitForActive(ctx context.Context, client DynamodbAPI, tableName string) (*types.TableDescription, error) {
+	for {
+		description, err := DescribeTable(ctx, client, tableName)
+		if err != nil {
+			return nil, err
+		}
+		pending := ""
+		if description.TableStatus != types.TableStatusActive {
+			pending = fmt.Sprintf("table %s is %s", tableName, description.TableStatus)
+		}
+		for _, index := range description.GlobalSecondaryIndexes {
+			if pending == "" && index.IndexStatus != types.IndexStatusActive {
+				pending = fmt.Sprintf("index %s is %s", aws.ToString(index.IndexName), index.IndexStatus)
+			}
+		}
+		if pending == "" {
+			return description, nil
+		}
+		if err := sleep(ctx, WaitInterval); err != nil {
+			return nil, fmt.Errorf("stopped waiting, %s [%w]", pending, err)
+		}
+	}
+}
+
+// WaitForDeleted polls until the table no longer exists
+func WaitForDeleted(ctx context.Context, client DynamodbAPI, tableName string) error {
+	for {
+		description, err := DescribeTable(ctx, client, tableName)
+		if err != nil {
+			if errors.Is(err, ErrTableNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := sleep(ctx, WaitInterval); err != nil {
+			return fmt.Errorf("stopped waiting, table %s is %s [%w]", tableName, description.TableStatus, err)
+		}
+	}
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// CreateTableInputLike returns the request creating a table with the keys,
+// indexes, billing, stream and deletion protection of the described table
+func CreateTableInputLike(description *types.TableDescription, tableName string) *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:                 &tableName,
+		AttributeDefinitions:      description.AttributeDefinitions,
+		KeySchema:                 description.KeySchema,
+		BillingMode:               types.BillingModeProvisioned,
+		StreamSpecification:       description.StreamSpecification,
+		DeletionProtectionEnabled: description.DeletionProtectionEnabled,
+	}
+	if description.BillingModeSummary != nil && description.BillingModeSummary.BillingMode != "" {
+		input.BillingMode = description.BillingModeSummary.BillingMode
+	}
+	if description.TableClassSummary != nil {
+		input.TableClass = description.TableClassSummary.TableClass
+	}
+	provisioned := input.BillingMode == types.BillingModeProvisioned
+	if provisioned {
+		input.ProvisionedThroughput = provisionedThroughput(description.ProvisionedThroughput)
+	}
+	for _, index := range description.GlobalSecondaryIndexes {
+		globalIndex := types.GlobalSecondaryIndex{
+			IndexName:  index.IndexName,
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		}
+		if provisioned {
+			globalIndex.ProvisionedThroughput = provisionedThroughput(index.ProvisionedThroughput)
+		}
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, globalIndex)
+	}
+	for _, index := range description.LocalSecondaryIndexes {
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, types.LocalSecondaryIndex{
+			IndexName:  index.IndexName,
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		})
+	}
+	return input
+}
+
+func provisionedThroughput(description *types.ProvisionedThroughputDescription) *types.ProvisionedThroughput {
+	if description == nil {
+		return nil
+	}
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  description.ReadCapacityUnits,
+		WriteCapacityUnits: description.WriteCapacityUnits,
+	}
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ItemTemplate formats unmarshalled items with a go text/template
+type ItemTemplate struct {
+	template *template.Template
+}
+
+// TemplateFuncs are the helper functions of item templates
+var TemplateFuncs = template.FuncMap{
+	"date":       func(layout string, epoch any) (string, error) { return formatEpoch(layout, epoch, time.Second) },
+	"dateMillis": func(layout string, epoch any) (string, error) { return formatEpoch(layout, epoch, time.Millisecond) },
+	"json":       templateJson,
+	"default":    templateDefault,
+	"truncate":   truncate,
+	"join":       join,
+}
+
+func ParseItemTemplate(name, text string) (*ItemTemplate, error) {
+	parsed, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template [%w]", err)
+	}
+	return &ItemTemplate{template: parsed}, nil
+}
+
+// Execute writes the template applied to a value, ending it with a newline
+// unless it is empty or already does
+func (itemTemplate *ItemTemplate) Execute(writer io.Writer, value any) error {
+	data, err := JsonValue(value)
+	if err != nil {
+		return err
+	}
+	var output bytes.Buffer
+	if err := itemTemplate.template.Execute(&output, templateNumbers(data)); err != nil {
+		return fmt.Errorf("failed to execute template [%w]", err)
+	}
+	if output.Len() > 0 && !bytes.HasSuffix(output.Bytes(), []byte("\n")) {
+		output.WriteByte('\n')
+	}
+	_, err = writer.Write(output.Bytes())
+	return err
+}
+
+// templateNumbers converts json numbers to int64, or float64 with a fraction,
+// so they print without exponents and compare with numbers in the template
+func templateNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		number, _ := value.Float64()
+		return number
+	case []any:
+		for index, element := range value {
+			value[index] = templateNumbers(element)
+		}
+	case map[string]any:
+		for name, element := range value {
+			value[name] = templateNumbers(element)
+		}
+	}
+	return value
+}
+
+// formatEpoch formats a number of seconds or milliseconds since the epoch in
+// UTC, empty for missing values. The layout is a go layout or rfc3339
+func formatEpoch(layout string, epoch any, unit time.Duration) (string, error) {
+	if epoch == nil {
+		return "", nil
+	}
+	number, err := strconv.ParseFloat(fmt.Sprint(epoch), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid epoch time %v", epoch)
+	}
+	if strings.EqualFold(layout, "rfc3339") {
+		layout = time.RFC3339
+	}
+	return time.Unix(0, int64(number*float64(unit))).UTC().Format(layout), nil
+}
+
+func templateJson(value any) (string, error) {
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to Marshal %T as json [%w]", value, err)
+	}
+	return string(valueJson), nil
+}
+
+// templateDefault returns the value unless it is missing or empty, e.g.
+// {{default "-" .status}}
+func templateDefault(fallback any, value any) any {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// truncate shortens the text of a value to length characters, ending it with …
+func truncate(length int, value any) string {
+	text := templateText(value)
+	if runes := []rune(text); length > 0 && len(runes) > length {
+		return string(runes[:length-1]) + "…"
+	}
+	return text
+}
+
+// join joins the text of values, e.g. the key of an item {{join "/" .pk .sk}},
+// the elements of lists are joined too
+func join(separator string, values ...any) string {
+	var texts []string
+	for _, value := range values {
+		if list, ok := value.([]any); ok {
+			for _, element := range list {
+				texts = append(texts, templateText(element))
+			}
+			continue
+		}
+		texts = append(texts, templateText(value))
+	}
+	return strings.Join(texts, separator)
+}
+
+// templateText is how a template prints a value, but empty for missing values
+// and json for lists and maps
+func templateText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []any, map[string]any:
+		text, _ := templateJson(value)
+		return text
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestItemTemplate(t *testing.T) {
+	itemTemplate, err := ParseItemTemplate("test", `{{date "2006-01-02T15:04" .expires}} {{dateMillis "rfc3339" .created}} {{json .tags}} `+
+		`{{default "none" .missing}} {{truncate 5 .note}} {{join "#" .pk .sk}} {{.count}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output strings.Builder
+	err = itemTemplate.Execute(&output, map[string]any{
+		"expires": 1735689600.0,
+		"created": 1735689600500.0,
+		"tags":    []string{"a", "b"},
+		"note":    "a long note",
+		"pk":      "CUSTOMER#1",
+		"sk":      2.0,
+		"count":   1000000.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2025-01-01T00:00 2025-01-01T00:00:00Z [\"a\",\"b\"] none a lo… CUSTOMER#1#2 1000000\n"; output.String() != want {
+		t.Errorf("got %q want %q", output.String(), want)
+	}
+
+	itemTemplate, _ = ParseItemTemplate("test", `{{date "2006" .note}}`)
+	if err := itemTemplate.Execute(&output, map[string]any{"note": "soon"}); err == nil {
+		t.Errorf("got no error formatting a string as a date")
+	}
+}
@@ -6,14 +6,20 @@ import (
 	"github.com/tidwall/pretty"
 )
 
-func PrintJson(json []byte, prettyPrint bool, colorOutput bool) {
-	formatString := "%s\n"
+func FormatJson(json []byte, prettyPrint bool, colorOutput bool) []byte {
 	if prettyPrint {
 		json = pretty.Pretty(json)
-		formatString = "%s" // pretty.Pretty adds a newline
 	}
 	if colorOutput {
 		json = pretty.Color(json, nil)
 	}
-	fmt.Printf(formatString, json)
+	return json
+}
+
+func PrintJson(json []byte, prettyPrint bool, colorOutput bool) {
+	formatString := "%s\n"
+	if prettyPrint {
+		formatString = "%s" // pretty.Pretty adds a newline
+	}
+	fmt.Printf(formatString, FormatJson(json, prettyPrint, colorOutput))
 }